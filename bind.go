@@ -74,6 +74,16 @@ func (b *DefaultBinder) Bind(i interface{}, c Context) (err error) {
 }
 
 func (b *DefaultBinder) bindData(ptr interface{}, data map[string][]string, tag string) error {
+	return b.bindFields(ptr, data, tag, false)
+}
+
+// bindTaggedData binds only the fields with the tag, so untagged fields, e.g.
+// of a JSON body, can't be set from the data.
+func (b *DefaultBinder) bindTaggedData(ptr interface{}, data map[string][]string, tag string) error {
+	return b.bindFields(ptr, data, tag, true)
+}
+
+func (b *DefaultBinder) bindFields(ptr interface{}, data map[string][]string, tag string, tagged bool) error {
 	typ := reflect.TypeOf(ptr).Elem()
 	val := reflect.ValueOf(ptr).Elem()
 
@@ -94,11 +104,14 @@ func (b *DefaultBinder) bindData(ptr interface{}, data map[string][]string, tag
 			inputFieldName = typeField.Name
 			// If tag is nil, we inspect if the field is a struct.
 			if _, ok := bindUnmarshaler(structField); !ok && structFieldKind == reflect.Struct {
-				if err := b.bindData(structField.Addr().Interface(), data, tag); err != nil {
+				if err := b.bindFields(structField.Addr().Interface(), data, tag, tagged); err != nil {
 					return err
 				}
 				continue
 			}
+			if tagged {
+				continue
+			}
 		}
 
 		inputValue, exists := data[inputFieldName]
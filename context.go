@@ -2,6 +2,7 @@ package nio
 
 import (
 	"bytes"
+	stdcontext "context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-nio/nio/log"
 )
@@ -19,13 +21,24 @@ import (
 type (
 	// Context represents the context of the current HTTP request. It holds request and
 	// response objects, path, path parameters, data and registered handler.
+	//
+	// Context implements `context.Context` on top of the request context, so it
+	// can be passed to any function expecting one. It must not be retained after
+	// the handler returns.
 	Context interface {
+		stdcontext.Context
+
 		// Request returns `*http.Request`.
 		Request() *http.Request
 
-		// SetRequest sets `*http.Request`.
+		// SetRequest sets `*http.Request`. Values attached with SetValue are
+		// carried over to the new request context.
 		SetRequest(r *http.Request)
 
+		// SetValue attaches a value to the request context, making it available
+		// through Value and `Request().Context().Value()`.
+		SetValue(key, val interface{})
+
 		// Response returns `*Response`.
 		Response() *Response
 
@@ -83,13 +96,18 @@ type (
 		// Get retrieves data from the context.
 		Get(key string) interface{}
 
-		// Set saves data in the context.
+		// Set saves data in the context. See `NewKey()` for a typed alternative
+		// which avoids collisions between middleware.
 		Set(key string, val interface{})
 
 		// Bind binds the request body into provided type `i`. The default binder
 		// does it based on Content-Type header.
 		Bind(i interface{}) error
 
+		// Validate validates provided `i`. It is usually called after `Context#Bind()`.
+		// Validator must be registered using `nio.WithValidator`.
+		Validate(i interface{}) error
+
 		// Render renders a template with data and sends a text/html response with status
 		// code. Renderer must be registered using `nio.Renderer`.
 		Render(code int, name string, data interface{}) error
@@ -135,6 +153,10 @@ type (
 		// Stream sends a streaming response with status code and content type.
 		Stream(code int, contentType string, r io.Reader) error
 
+		// SSE sends the Server-Sent Events response headers and returns a stream
+		// to write events to.
+		SSE() *EventStream
+
 		// File sends a response with the content of the file.
 		File(file string) error
 
@@ -154,6 +176,26 @@ type (
 		// Error invokes the registered HTTP error handler. Generally used by middleware.
 		Error(err error)
 
+		// Route returns the route matched by router or nil if no route matched,
+		// e.g. for 404 and 405 responses.
+		Route() *Route
+
+		// AllowedMethods returns the methods registered for the path matched by
+		// router, including OPTIONS if enabled with `WithAutoOptions()`.
+		AllowedMethods() []string
+
+		// RealIP returns the IP of the client, see `WithIPExtractor()`.
+		RealIP() string
+
+		// Scheme returns the scheme of the request, "http" or "https". Forwarding
+		// headers such as `X-Forwarded-Proto` are only honored for requests from
+		// trusted proxies, see `WithTrustedProxies()`.
+		Scheme() string
+
+		// IsTLS reports whether the client connected with TLS, directly or to a
+		// trusted proxy.
+		IsTLS() bool
+
 		// Handler returns the matched handler by router.
 		Handler() HandlerFunc
 
@@ -175,9 +217,36 @@ type (
 		pvalues  []string
 		query    url.Values
 		handler  HandlerFunc
+		allowed  []string
+		head     *headResponseWriter
 		store    map[string]interface{}
+		slots    []slot
+		values   *contextValues
 		nio      *Nio
+
+		// Matched route, resolved lazily by Route()
+		methodHandler *methodHandler
+		routeMethod   string
+	}
+
+	// slot holds the value of a `Key`, see `keySlot`.
+	slot interface {
+		reset()
+	}
+
+	// contextValues holds the values attached with `Context#SetValue()`.
+	contextValues struct {
+		keys []interface{}
+		vals []interface{}
+	}
+
+	// valueContext exposes contextValues through a `context.Context`.
+	valueContext struct {
+		stdcontext.Context
+		values *contextValues
 	}
+
+	contextValuesKey struct{}
 )
 
 const (
@@ -197,9 +266,54 @@ func (c *context) Request() *http.Request {
 }
 
 func (c *context) SetRequest(r *http.Request) {
+	if c.values != nil && r.Context().Value(contextValuesKey{}) != c.values {
+		r = r.WithContext(&valueContext{Context: r.Context(), values: c.values})
+	}
 	c.request = r
 }
 
+func (c *context) SetValue(key, val interface{}) {
+	if c.values == nil {
+		c.values = new(contextValues)
+		c.request = c.request.WithContext(&valueContext{Context: c.request.Context(), values: c.values})
+	}
+	c.values.keys = append(c.values.keys, key)
+	c.values.vals = append(c.values.vals, val)
+}
+
+func (c *context) Deadline() (deadline time.Time, ok bool) {
+	if c.request == nil {
+		return
+	}
+	return c.request.Context().Deadline()
+}
+
+func (c *context) Done() <-chan struct{} {
+	if c.request == nil {
+		return nil
+	}
+	return c.request.Context().Done()
+}
+
+func (c *context) Err() error {
+	if c.request == nil {
+		return nil
+	}
+	return c.request.Context().Err()
+}
+
+func (c *context) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		if v, ok := c.store[k]; ok {
+			return v
+		}
+	}
+	if c.request == nil {
+		return nil
+	}
+	return c.request.Context().Value(key)
+}
+
 func (c *context) Response() *Response {
 	return c.response
 }
@@ -307,10 +421,33 @@ func (c *context) Set(key string, val interface{}) {
 	c.store[key] = val
 }
 
+func (c *context) slot(id int) slot {
+	if id < len(c.slots) {
+		return c.slots[id]
+	}
+	return nil
+}
+
+func (c *context) setSlot(id int, s slot) {
+	if id >= len(c.slots) {
+		slots := make([]slot, id+1)
+		copy(slots, c.slots)
+		c.slots = slots
+	}
+	c.slots[id] = s
+}
+
 func (c *context) Bind(i interface{}) error {
 	return c.nio.binder.Bind(i, c)
 }
 
+func (c *context) Validate(i interface{}) error {
+	if c.nio.validator == nil {
+		return ErrValidatorNotRegistered
+	}
+	return c.nio.validator.Validate(i)
+}
+
 func (c *context) Render(code int, name string, data interface{}) (err error) {
 	if c.nio.renderer == nil {
 		return ErrRendererNotRegistered
@@ -423,6 +560,10 @@ func (c *context) Stream(code int, contentType string, r io.Reader) (err error)
 	return
 }
 
+func (c *context) SSE() *EventStream {
+	return newEventStream(c)
+}
+
 func (c *context) File(file string) (err error) {
 	f, err := os.Open(file)
 	if err != nil {
@@ -481,6 +622,29 @@ func (c *context) Nio() *Nio {
 	return c.nio
 }
 
+func (c *context) Route() *Route {
+	if c.methodHandler == nil {
+		return nil
+	}
+	return c.methodHandler.routes[c.routeMethod]
+}
+
+func (c *context) AllowedMethods() []string {
+	return c.allowed
+}
+
+func (c *context) RealIP() string {
+	return c.nio.ipExtractor(c.request)
+}
+
+func (c *context) Scheme() string {
+	return c.nio.trustedProxies.scheme(c.request)
+}
+
+func (c *context) IsTLS() bool {
+	return c.Scheme() == "https"
+}
+
 func (c *context) Handler() HandlerFunc {
 	return c.handler
 }
@@ -499,8 +663,32 @@ func (c *context) reset(r *http.Request, w http.ResponseWriter) {
 	c.query = nil
 	c.handler = NotFoundHandler
 	c.store = nil
+	// Slots are cleared in place to be reused
+	for _, s := range c.slots {
+		if s != nil {
+			s.reset()
+		}
+	}
+	c.values = nil
 	c.path = ""
 	c.pnames = nil
+	c.allowed = nil
+	c.head = nil
+	c.methodHandler = nil
+	c.routeMethod = ""
 	// NOTE: Don't reset because it has to have length c.nio.maxParam at all times
 	// c.pvalues = nil
 }
+
+func (v *valueContext) Value(key interface{}) interface{} {
+	if key == (contextValuesKey{}) {
+		return v.values
+	}
+	// Latest value wins
+	for i := len(v.values.keys) - 1; i >= 0; i-- {
+		if v.values.keys[i] == key {
+			return v.values.vals[i]
+		}
+	}
+	return v.Context.Value(key)
+}
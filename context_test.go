@@ -2,6 +2,7 @@ package nio
 
 import (
 	"bytes"
+	stdcontext "context"
 	"encoding/xml"
 	"errors"
 	"io"
@@ -402,6 +403,56 @@ func TestContextStore(t *testing.T) {
 	assert.Equal(t, "Jon Snow", c.Get("name"))
 }
 
+func TestContextStdContext(t *testing.T) {
+	e := New()
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := stdcontext.WithDeadline(stdcontext.Background(), deadline)
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	assert := assert.New(t)
+
+	d, ok := c.Deadline()
+	assert.True(ok)
+	assert.Equal(deadline, d)
+	assert.NoError(c.Err())
+
+	c.Set("name", "Jon Snow")
+	assert.Equal("Jon Snow", c.Value("name"))
+
+	cancel()
+	<-c.Done()
+	assert.Equal(stdcontext.Canceled, c.Err())
+
+	// Without request
+	c = new(context)
+	assert.Nil(c.Done())
+	assert.NoError(c.Err())
+	assert.Nil(c.Value("name"))
+}
+
+func TestContextSetValue(t *testing.T) {
+	type key struct{}
+	e := New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	assert := assert.New(t)
+
+	c.SetValue(key{}, "value")
+	assert.Equal("value", c.Value(key{}))
+	assert.Equal("value", c.Request().Context().Value(key{}))
+
+	// Survives SetRequest
+	c.SetRequest(req.WithContext(stdcontext.Background()))
+	assert.Equal("value", c.Value(key{}))
+	assert.Equal("value", c.Request().Context().Value(key{}))
+
+	// Latest value wins
+	c.SetValue(key{}, "other")
+	assert.Equal("other", c.Request().Context().Value(key{}))
+}
+
 func TestContextHandler(t *testing.T) {
 	e := New()
 	b := new(bytes.Buffer)
@@ -1,59 +1,45 @@
 package todo
 
 import (
-	"net/http"
-
 	"github.com/go-nio/nio"
 )
 
 // RegisterHandlers registers todo routes and inject todo store.
 func RegisterHandlers(n *nio.Nio, store TodoStore) {
 	h := &handlers{store: store}
-	n.GET("/todos", h.GetAllTodos)
-	n.GET("/todos/:id", h.GetTodoByID)
-	n.POST("/todos", h.AddTodo)
-	n.DELETE("/todos/:id", h.DeleteTodo)
+	n.GET("/todos", nio.Typed(h.GetAllTodos))
+	n.GET("/todos/:id", nio.Typed(h.GetTodoByID))
+	n.POST("/todos", nio.Typed(h.AddTodo))
+	n.DELETE("/todos/:id", nio.Typed(h.DeleteTodo))
 }
 
 type handlers struct {
 	store TodoStore
 }
 
-func (h *handlers) GetAllTodos(c nio.Context) error {
-	allTodos, err := h.store.GetAll()
-	if err != nil {
-		return err
-	}
-	return c.JSON(http.StatusOK, allTodos)
+type todoIDRequest struct {
+	ID string `param:"id"`
+}
+
+func (h *handlers) GetAllTodos(c nio.Context, _ struct{}) ([]*todo, error) {
+	return h.store.GetAll()
 }
 
-func (h *handlers) GetTodoByID(c nio.Context) error {
-	todoID := c.Param("id")
-	todo, err := h.store.GetByID(todoID)
+func (h *handlers) GetTodoByID(c nio.Context, req todoIDRequest) (*todo, error) {
+	todo, err := h.store.GetByID(req.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if todo == nil {
-		return nio.ErrNotFound
+		return nil, nio.ErrNotFound
 	}
-	return c.JSON(http.StatusOK, todo)
+	return todo, nil
 }
 
-func (h *handlers) AddTodo(c nio.Context) error {
-	newTodo := &todo{}
-	if err := c.Bind(newTodo); err != nil {
-		return err
-	}
-	if err := h.store.Add(newTodo); err != nil {
-		return err
-	}
-	return c.NoContent(http.StatusOK)
+func (h *handlers) AddTodo(c nio.Context, newTodo *todo) (struct{}, error) {
+	return struct{}{}, h.store.Add(newTodo)
 }
 
-func (h *handlers) DeleteTodo(c nio.Context) error {
-	todoID := c.Param("id")
-	if err := h.store.Delete(todoID); err != nil {
-		return err
-	}
-	return c.NoContent(http.StatusOK)
+func (h *handlers) DeleteTodo(c nio.Context, req todoIDRequest) (struct{}, error) {
+	return struct{}{}, h.store.Delete(req.ID)
 }
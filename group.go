@@ -22,9 +22,9 @@ func (g *Group) Use(middleware ...MiddlewareFunc) {
 	// Allow all requests to reach the group as they might get dropped if router
 	// doesn't find a match, making none of the group middleware process.
 	for _, p := range []string{"", "/*"} {
-		g.nio.Any(path.Clean(g.prefix+p), func(c Context) error {
+		g.nio.any(path.Clean(g.prefix+p), func(c Context) error {
 			return NotFoundHandler(c)
-		}, g.middleware...)
+		}, true, g.middleware)
 	}
 }
 
@@ -73,11 +73,19 @@ func (g *Group) TRACE(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
 	return g.Add(http.MethodTrace, path, h, m...)
 }
 
+// WS implements `Nio#WS()` for sub-routes within the Group.
+func (g *Group) WS(path string, h WebSocketHandler, m ...MiddlewareFunc) *Route {
+	return g.GET(path, WebSocket(h), m...).NoAutoHead()
+}
+
 // Any implements `Nio#Any()` for sub-routes within the Group.
 func (g *Group) Any(path string, handler HandlerFunc, middleware ...MiddlewareFunc) []*Route {
-	routes := make([]*Route, len(methods))
-	for i, m := range methods {
-		routes[i] = g.Add(m, path, handler, middleware...)
+	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
+	m = append(m, g.middleware...)
+	m = append(m, middleware...)
+	routes := g.nio.Any(g.prefix+path, handler, m...)
+	for _, r := range routes {
+		r.Prefix = g.prefix
 	}
 	return routes
 }
@@ -99,6 +107,14 @@ func (g *Group) Group(prefix string, middleware ...MiddlewareFunc) *Group {
 	return g.nio.Group(g.prefix+prefix, m...)
 }
 
+// Mount implements `Nio#Mount()` for sub-routes within the Group.
+func (g *Group) Mount(prefix string, h http.Handler, middleware ...MiddlewareFunc) {
+	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
+	m = append(m, g.middleware...)
+	m = append(m, middleware...)
+	g.nio.Mount(g.prefix+prefix, h, m...)
+}
+
 // Static implements `Nio#Static()` for sub-routes within the Group.
 func (g *Group) Static(prefix, root string) {
 	static(g, prefix, root)
@@ -117,5 +133,7 @@ func (g *Group) Add(method, path string, handler HandlerFunc, middleware ...Midd
 	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
-	return g.nio.Add(method, g.prefix+path, handler, m...)
+	r := g.nio.Add(method, g.prefix+path, handler, m...)
+	r.Prefix = g.prefix
+	return r
 }
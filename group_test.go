@@ -2,6 +2,7 @@ package nio
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -65,3 +66,26 @@ func TestGroupRouteMiddleware(t *testing.T) {
 	c, _ = request(http.MethodGet, "/group/405", e)
 	assert.Equal(t, 405, c)
 }
+
+func TestGroupMount(t *testing.T) {
+	e := New()
+	g := e.Group("/api", func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			c.Response().Header().Set("X-Group", "1")
+			return next(c)
+		}
+	})
+	g.Mount("/legacy", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/legacy/users", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "/users", rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get("X-Group"))
+	if routes := e.Routes(); assert.Len(t, routes, 1) {
+		assert.Equal(t, "/api/legacy/*", routes[0].Path)
+		assert.Equal(t, "/api/legacy", routes[0].Prefix)
+	}
+}
@@ -0,0 +1,19 @@
+package route
+
+// ConstraintEnd returns the index of the '>' closing the param constraint
+// starting at i or -1. Constraints may contain nested '<' '>' pairs.
+func ConstraintEnd(path string, i int) int {
+	depth := 0
+	for ; i < len(path); i++ {
+		switch path[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,279 @@
+package nio
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+type (
+	// IPExtractor returns the real IP of the client of the request, see
+	// `WithIPExtractor()`.
+	IPExtractor func(r *http.Request) string
+
+	// trustedProxies are the networks of proxies whose forwarding headers are
+	// trusted.
+	trustedProxies []*net.IPNet
+)
+
+// DefaultTrustedProxies are the networks of proxies trusted by default:
+// loopback, link-local and private networks.
+var DefaultTrustedProxies = []string{
+	"127.0.0.0/8",
+	"::1/128",
+	"169.254.0.0/16",
+	"fe80::/10",
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"fc00::/7",
+}
+
+// WithTrustedProxies sets the IPs or CIDR ranges of the proxies whose
+// forwarding headers are trusted by the default IP extractor and
+// `Context#Scheme()`, replacing `DefaultTrustedProxies`. Without arguments no
+// proxy is trusted.
+func WithTrustedProxies(proxies ...string) Option {
+	return func(o *options) {
+		o.trustedProxies = parseTrustedProxies(proxies)
+	}
+}
+
+// WithIPExtractor sets the extractor of `Context#RealIP()`. By default the IP
+// is extracted from the `X-Forwarded-For` header of requests from trusted
+// proxies, see `ExtractIPFromXFFHeader()`.
+func WithIPExtractor(extractor IPExtractor) Option {
+	return func(o *options) {
+		o.ipExtractor = extractor
+	}
+}
+
+// ExtractIPDirect returns an extractor of the IP of the direct peer, for
+// servers exposed directly to clients.
+func ExtractIPDirect() IPExtractor {
+	return directIP
+}
+
+// ExtractIPFromXFFHeader returns an extractor of the IP from the
+// `X-Forwarded-For` header. The header is walked from the nearest hop and the
+// first IP which isn't a trusted proxy is the client IP. The proxies are IPs
+// or CIDR ranges, `DefaultTrustedProxies` if none are given.
+func ExtractIPFromXFFHeader(proxies ...string) IPExtractor {
+	return extractIPFromXFFHeader(trustedOrDefault(proxies))
+}
+
+func extractIPFromXFFHeader(trusted trustedProxies) IPExtractor {
+	return func(r *http.Request) string {
+		direct := directIP(r)
+		if !trusted.trusts(direct) {
+			return direct
+		}
+		return trusted.client(direct, headerList(r, HeaderXForwardedFor))
+	}
+}
+
+// ExtractIPFromRealIPHeader returns an extractor of the IP from the
+// `X-Real-IP` header, set by a trusted proxy. The proxies are IPs or CIDR
+// ranges, `DefaultTrustedProxies` if none are given.
+func ExtractIPFromRealIPHeader(proxies ...string) IPExtractor {
+	trusted := trustedOrDefault(proxies)
+	return func(r *http.Request) string {
+		direct := directIP(r)
+		if !trusted.trusts(direct) {
+			return direct
+		}
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get(HeaderXRealIP))); ip != nil {
+			return ip.String()
+		}
+		return direct
+	}
+}
+
+// ExtractIPFromForwardedHeader returns an extractor of the IP from the `for`
+// parameters of the RFC 7239 `Forwarded` header, walked like
+// `ExtractIPFromXFFHeader()`. The proxies are IPs or CIDR ranges,
+// `DefaultTrustedProxies` if none are given.
+func ExtractIPFromForwardedHeader(proxies ...string) IPExtractor {
+	trusted := trustedOrDefault(proxies)
+	return func(r *http.Request) string {
+		direct := directIP(r)
+		if !trusted.trusts(direct) {
+			return direct
+		}
+		var hops []string
+		for _, element := range forwardedElements(r) {
+			hops = append(hops, forwardedNode(element["for"]))
+		}
+		return trusted.client(direct, hops)
+	}
+}
+
+// client walks the hops from the nearest to the farthest and returns the
+// first one which isn't trusted, the farthest if all are trusted, or direct if
+// a hop is invalid.
+func (t trustedProxies) client(direct string, hops []string) string {
+	i := t.clientHop(hops)
+	if i == -1 {
+		return direct
+	}
+	return net.ParseIP(hops[i]).String()
+}
+
+// clientHop returns the index of the client in the hops, see `client()`, or -1
+// if there are no hops or a hop is invalid.
+func (t trustedProxies) clientHop(hops []string) int {
+	if len(hops) == 0 {
+		return -1
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			return -1 // Malformed or obfuscated hop, e.g. "unknown"
+		}
+		if !t.trusts(ip.String()) {
+			return i
+		}
+	}
+	return 0
+}
+
+func (t trustedProxies) trusts(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range t {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTrustedProxies(proxies []string) trustedProxies {
+	t := make(trustedProxies, 0, len(proxies))
+	for _, p := range proxies {
+		cidr := p
+		if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+			cidr += "/32"
+		} else if ip != nil {
+			cidr += "/128"
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic("nio: invalid trusted proxy " + p)
+		}
+		t = append(t, n)
+	}
+	return t
+}
+
+func trustedOrDefault(proxies []string) trustedProxies {
+	if len(proxies) == 0 {
+		proxies = DefaultTrustedProxies
+	}
+	return parseTrustedProxies(proxies)
+}
+
+func directIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// forwardedElements parses the `Forwarded` headers into their elements, the
+// nearest hop last, with lower case parameter names and unquoted values.
+func forwardedElements(r *http.Request) []map[string]string {
+	var elements []map[string]string
+	for _, h := range r.Header.Values(HeaderForwarded) {
+		for _, e := range strings.Split(h, ",") {
+			element := map[string]string{}
+			for _, pair := range strings.Split(e, ";") {
+				if i := strings.IndexByte(pair, '='); i != -1 {
+					key := strings.ToLower(strings.TrimSpace(pair[:i]))
+					element[key] = strings.Trim(strings.TrimSpace(pair[i+1:]), `"`)
+				}
+			}
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// forwardedNode returns the IP of a `Forwarded` node without port, e.g.
+// `192.0.2.1:8080` or `[2001:db8::1]:8080`.
+func forwardedNode(node string) string {
+	if strings.HasPrefix(node, "[") {
+		if i := strings.IndexByte(node, ']'); i != -1 {
+			return node[1:i]
+		}
+		return node
+	}
+	if strings.Count(node, ":") == 1 {
+		return node[:strings.IndexByte(node, ':')]
+	}
+	return node
+}
+
+// scheme returns the scheme of the request, taken from the forwarding headers
+// if the direct peer is a trusted proxy. Like the client IP, the scheme is
+// taken from the hop of the nearest proxy facing an untrusted client, farther
+// hops are controlled by the client.
+func (t trustedProxies) scheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	if !t.trusts(directIP(r)) {
+		return "http"
+	}
+	if elements := forwardedElements(r); len(elements) > 0 {
+		hops := make([]string, len(elements))
+		for i, element := range elements {
+			hops[i] = forwardedNode(element["for"])
+		}
+		i := t.clientHop(hops)
+		if i == -1 {
+			i = len(elements) - 1 // Added by the direct peer
+		}
+		if proto := elements[i]["proto"]; proto != "" {
+			return strings.ToLower(proto)
+		}
+	}
+	if protos := headerList(r, HeaderXForwardedProto); len(protos) > 0 {
+		// Protos are appended like the IPs of X-Forwarded-For, align them from
+		// the nearest hop
+		hops := headerList(r, HeaderXForwardedFor)
+		nearest := 0
+		if i := t.clientHop(hops); i != -1 {
+			nearest = len(hops) - 1 - i
+		}
+		i := len(protos) - 1 - nearest
+		if i < 0 {
+			i = 0
+		}
+		return strings.ToLower(protos[i])
+	}
+	if proto := r.Header.Get(HeaderXForwardedProtocol); proto != "" {
+		return strings.ToLower(proto)
+	}
+	if r.Header.Get(HeaderXForwardedSsl) == "on" {
+		return "https"
+	}
+	if scheme := r.Header.Get(HeaderXUrlScheme); scheme != "" {
+		return strings.ToLower(scheme)
+	}
+	return "http"
+}
+
+// headerList returns the comma separated values of the header.
+func headerList(r *http.Request, key string) []string {
+	var values []string
+	for _, h := range r.Header.Values(key) {
+		for _, v := range strings.Split(h, ",") {
+			values = append(values, strings.TrimSpace(v))
+		}
+	}
+	return values
+}
@@ -0,0 +1,142 @@
+package nio
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractIP(t *testing.T) {
+	request := func(remote string, headers map[string][]string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remote
+		for k, values := range headers {
+			for _, v := range values {
+				req.Header.Add(k, v)
+			}
+		}
+		return req
+	}
+	xff := map[string][]string{HeaderXForwardedFor: {"203.0.113.9, 198.51.100.1", "10.0.0.2"}}
+
+	tests := []struct {
+		name      string
+		extractor IPExtractor
+		req       *http.Request
+		expected  string
+	}{
+		{"direct", ExtractIPDirect(), request("10.0.0.1:80", xff), "10.0.0.1"},
+		{"xff untrusted peer", ExtractIPFromXFFHeader(), request("192.0.2.1:80", xff), "192.0.2.1"},
+		{"xff first untrusted hop", ExtractIPFromXFFHeader(), request("10.0.0.1:80", xff), "198.51.100.1"},
+		{"xff trusted hops", ExtractIPFromXFFHeader("10.0.0.0/8", "198.51.100.1"), request("10.0.0.1:80", xff), "203.0.113.9"},
+		{"xff all trusted", ExtractIPFromXFFHeader(), request("10.0.0.1:80", map[string][]string{HeaderXForwardedFor: {"10.0.0.5"}}), "10.0.0.5"},
+		{"xff invalid hop", ExtractIPFromXFFHeader(), request("10.0.0.1:80", map[string][]string{HeaderXForwardedFor: {"unknown"}}), "10.0.0.1"},
+		{"xff missing", ExtractIPFromXFFHeader(), request("[::1]:80", nil), "::1"},
+		{"real ip", ExtractIPFromRealIPHeader(), request("127.0.0.1:80", map[string][]string{HeaderXRealIP: {"203.0.113.9"}}), "203.0.113.9"},
+		{"real ip untrusted peer", ExtractIPFromRealIPHeader(), request("192.0.2.1:80", map[string][]string{HeaderXRealIP: {"203.0.113.9"}}), "192.0.2.1"},
+		{"real ip invalid", ExtractIPFromRealIPHeader(), request("127.0.0.1:80", map[string][]string{HeaderXRealIP: {"x"}}), "127.0.0.1"},
+		{"forwarded", ExtractIPFromForwardedHeader(), request("10.0.0.1:80", map[string][]string{
+			HeaderForwarded: {`for="[2001:db8::1]:4711";proto=https, for=198.51.100.1:80;by=10.0.0.1`},
+		}), "198.51.100.1"},
+		{"forwarded trusted hops", ExtractIPFromForwardedHeader("10.0.0.0/8", "198.51.100.0/24"), request("10.0.0.1:80", map[string][]string{
+			HeaderForwarded: {`for="[2001:db8::1]:4711";proto=https, for=198.51.100.1:80`},
+		}), "2001:db8::1"},
+		{"forwarded obfuscated", ExtractIPFromForwardedHeader(), request("10.0.0.1:80", map[string][]string{
+			HeaderForwarded: {"for=_hidden"},
+		}), "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.extractor(tt.req))
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	trusted := parseTrustedProxies([]string{"192.0.2.1", "2001:db8::1", "10.0.0.0/8"})
+	assert.True(t, trusted.trusts("192.0.2.1"))
+	assert.False(t, trusted.trusts("192.0.2.2"))
+	assert.True(t, trusted.trusts("2001:db8::1"))
+	assert.True(t, trusted.trusts("10.1.2.3"))
+	assert.False(t, trusted.trusts("invalid"))
+	assert.PanicsWithValue(t, "nio: invalid trusted proxy 10.0.0.0/33", func() {
+		parseTrustedProxies([]string{"10.0.0.0/33"})
+	})
+	assert.PanicsWithValue(t, "nio: invalid trusted proxy example.com", func() {
+		parseTrustedProxies([]string{"example.com"})
+	})
+}
+
+func TestContextRealIPScheme(t *testing.T) {
+	e := New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set(HeaderXForwardedFor, "203.0.113.9")
+	req.Header.Set(HeaderXForwardedProto, "HTTPS")
+	c := e.NewContext(req, nil)
+	assert.Equal(t, "203.0.113.9", c.RealIP())
+	assert.Equal(t, "https", c.Scheme())
+	assert.True(t, c.IsTLS())
+
+	// Untrusted peer
+	e = New(WithTrustedProxies())
+	c = e.NewContext(req, nil)
+	assert.Equal(t, "10.0.0.1", c.RealIP())
+	assert.Equal(t, "http", c.Scheme())
+	assert.False(t, c.IsTLS())
+
+	// Custom extractor and proxies
+	e = New(WithTrustedProxies("10.0.0.1"), WithIPExtractor(ExtractIPDirect()))
+	c = e.NewContext(req, nil)
+	assert.Equal(t, "10.0.0.1", c.RealIP())
+	assert.Equal(t, "https", c.Scheme())
+
+	// Other forwarding headers
+	for _, h := range []map[string]string{
+		{HeaderForwarded: "for=203.0.113.9;proto=https"},
+		{HeaderXForwardedProtocol: "https"},
+		{HeaderXForwardedSsl: "on"},
+		{HeaderXUrlScheme: "https"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "127.0.0.1:1234"
+		for k, v := range h {
+			req.Header.Set(k, v)
+		}
+		assert.True(t, New().NewContext(req, nil).IsTLS(), h)
+	}
+
+	// Proto of the hop the client IP is taken from, farther hops are forged
+	for _, h := range []map[string]string{
+		{HeaderForwarded: "for=192.0.2.1;proto=https, for=203.0.113.9;proto=http"},
+		{HeaderXForwardedFor: "192.0.2.1, 203.0.113.9", HeaderXForwardedProto: "https, http"},
+		{HeaderXForwardedFor: "203.0.113.9", HeaderXForwardedProto: "https, http"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		for k, v := range h {
+			req.Header.Set(k, v)
+		}
+		assert.Equal(t, "http", New().NewContext(req, nil).Scheme(), h)
+	}
+	for _, h := range []map[string]string{
+		{HeaderForwarded: "for=203.0.113.9;proto=https, for=10.0.0.2;proto=http"},
+		{HeaderXForwardedFor: "203.0.113.9, 10.0.0.2", HeaderXForwardedProto: "https, http"},
+		{HeaderForwarded: "for=unknown;proto=https"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		for k, v := range h {
+			req.Header.Set(k, v)
+		}
+		assert.Equal(t, "https", New().NewContext(req, nil).Scheme(), h)
+	}
+
+	// Direct TLS
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{}
+	assert.Equal(t, "https", New().NewContext(req, nil).Scheme())
+}
@@ -0,0 +1,82 @@
+package nio
+
+import (
+	"strconv"
+	"sync/atomic"
+)
+
+type (
+	// Key is a typed key to store request scoped values in the context. Keys
+	// never collide, even if created with the same name, and values are stored
+	// in a slot reused between requests instead of the `Context#Set()` map.
+	Key[T any] struct {
+		id       int
+		name     string
+		storeKey string
+	}
+
+	// keySlot holds the value of a key in a context slot. It's kept between
+	// requests and cleared in place, so setting a value doesn't allocate.
+	keySlot[T any] struct {
+		v  T
+		ok bool
+	}
+)
+
+var keys int32
+
+// NewKey creates a new typed key. Keys are meant to be created once, typically
+// as package level variables.
+//
+// Setting a value doesn't allocate once the context has held a value of the
+// key, whatever the type of the values.
+func NewKey[T any](name string) *Key[T] {
+	id := int(atomic.AddInt32(&keys, 1)) - 1
+	return &Key[T]{
+		id:       id,
+		name:     name,
+		storeKey: name + "#" + strconv.Itoa(id),
+	}
+}
+
+// Name returns the name the key was created with.
+func (k *Key[T]) Name() string {
+	return k.name
+}
+
+// Get returns the value stored in the context or the zero value of T.
+func (k *Key[T]) Get(c Context) T {
+	v, _ := k.Lookup(c)
+	return v
+}
+
+// Lookup returns the value stored in the context and whether it was present.
+func (k *Key[T]) Lookup(c Context) (v T, ok bool) {
+	if ctx, isContext := c.(*context); isContext {
+		if s, isSlot := ctx.slot(k.id).(*keySlot[T]); isSlot {
+			v, ok = s.v, s.ok
+		}
+		return
+	}
+	// Custom Context implementation
+	v, ok = c.Get(k.storeKey).(T)
+	return
+}
+
+// Set stores the value in the context.
+func (k *Key[T]) Set(c Context, v T) {
+	if ctx, ok := c.(*context); ok {
+		if s, isSlot := ctx.slot(k.id).(*keySlot[T]); isSlot {
+			s.v, s.ok = v, true
+			return
+		}
+		ctx.setSlot(k.id, &keySlot[T]{v: v, ok: true})
+		return
+	}
+	c.Set(k.storeKey, v)
+}
+
+func (s *keySlot[T]) reset() {
+	var zero T
+	s.v, s.ok = zero, false
+}
@@ -0,0 +1,96 @@
+package nio
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type customContext struct {
+	Context
+}
+
+func TestKey(t *testing.T) {
+	e := New()
+	c := e.NewContext(nil, nil)
+	name := NewKey[string]("name")
+	other := NewKey[string]("name")
+	count := NewKey[int]("count")
+
+	assert := assert.New(t)
+
+	assert.Equal("name", name.Name())
+	_, ok := name.Lookup(c)
+	assert.False(ok)
+	assert.Equal(0, count.Get(c))
+
+	name.Set(c, "Jon Snow")
+	count.Set(c, 3)
+	v, ok := name.Lookup(c)
+	assert.True(ok)
+	assert.Equal("Jon Snow", v)
+	assert.Equal(3, count.Get(c))
+
+	// Same name doesn't collide
+	_, ok = other.Lookup(c)
+	assert.False(ok)
+	assert.Nil(c.Get("name"))
+
+	// Custom context falls back to the store
+	cc := &customContext{Context: c}
+	other.Set(cc, "Arya Stark")
+	assert.Equal("Arya Stark", other.Get(cc))
+}
+
+func TestKeyReset(t *testing.T) {
+	e := New()
+	key := NewKey[*user]("user")
+	c := e.NewContext(nil, nil).(*context)
+	key.Set(c, &user{ID: 1})
+
+	c.reset(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	_, ok := key.Lookup(c)
+	assert.False(t, ok)
+	// The slot is kept, without holding on to the value
+	assert.Nil(t, c.slots[key.id].(*keySlot[*user]).v)
+}
+
+func TestKeyAllocs(t *testing.T) {
+	e := New()
+	key := NewKey[*user]("user")
+	c := e.NewContext(nil, nil)
+	u := &user{ID: 1}
+	key.Set(c, u)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		key.Set(c, u)
+		key.Get(c)
+	})
+	assert.Equal(t, 0.0, allocs)
+
+	// Values which aren't pointer-shaped don't allocate either
+	name := NewKey[string]("name")
+	n := strings.Repeat("a", 2)
+	name.Set(c, n)
+	allocs = testing.AllocsPerRun(100, func() {
+		name.Set(c, n)
+		name.Get(c)
+	})
+	assert.Equal(t, 0.0, allocs)
+
+	count := NewKey[int]("count")
+	i := 1000
+	count.Set(c, i)
+	allocs = testing.AllocsPerRun(100, func() {
+		count.Set(c, i)
+		count.Get(c)
+	})
+	assert.Equal(t, 0.0, allocs)
+	allocs = testing.AllocsPerRun(100, func() {
+		count.Get(c)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
@@ -38,6 +38,9 @@ var (
 		Skipper: nio.DefaultSkipper,
 		Realm:   defaultRealm,
 	}
+
+	// BasicAuthUserKey is the context key holding the authenticated username.
+	BasicAuthUserKey = nio.NewKey[string]("basic-auth-user")
 )
 
 // BasicAuth returns an BasicAuth middleware.
@@ -86,6 +89,7 @@ func BasicAuthWithConfig(config BasicAuthConfig) nio.MiddlewareFunc {
 						if err != nil {
 							return err
 						} else if valid {
+							BasicAuthUserKey.Set(c, cred[:i])
 							return next(c)
 						}
 						break
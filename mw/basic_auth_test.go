@@ -32,6 +32,7 @@ func TestBasicAuth(t *testing.T) {
 	auth := basic + " " + base64.StdEncoding.EncodeToString([]byte("joe:secret"))
 	req.Header.Set(nio.HeaderAuthorization, auth)
 	assert.NoError(h(c))
+	assert.Equal("joe", BasicAuthUserKey.Get(c))
 
 	h = BasicAuthWithConfig(BasicAuthConfig{
 		Skipper:   nil,
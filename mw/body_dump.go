@@ -3,12 +3,16 @@ package mw
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
-	"io/ioutil"
+	"mime"
 	"net"
 	"net/http"
+	"strings"
 
 	"github.com/go-nio/nio"
+	nbytes "github.com/go-nio/nio/internal/bytes"
 )
 
 type (
@@ -18,30 +22,98 @@ type (
 		Skipper nio.Skipper
 
 		// Handler receives request and response payload.
-		// Required.
+		// Required, unless RecordHandler is set.
 		Handler BodyDumpHandler
+
+		// RecordHandler receives the request and response payload together with
+		// the status code and the headers.
+		// Optional. Default value nil.
+		RecordHandler func(nio.Context, BodyDumpRecord)
+
+		// MaxSize is the maximum size of the captured request and response
+		// payload, the rest is passed through without capturing. It can be
+		// specified as `4x` or `4xB`, where x is one of the multiple from K, M,
+		// G, T or P.
+		// Optional. Default value "64KB".
+		MaxSize string `yaml:"max_size"`
+		maxSize int64
+
+		// ContentTypes limits capturing to payloads with the media types, a
+		// type ending with "/" matches all its subtypes, e.g. "text/".
+		// Optional. Default value []string{} which captures all payloads.
+		ContentTypes []string `yaml:"content_types"`
+
+		// RedactJSONFields are the names of JSON fields, at any depth, whose
+		// values are replaced with "[REDACTED]" in JSON payloads. Names are
+		// matched case-insensitively. JSON payloads which can't be parsed, e.g.
+		// because they exceed MaxSize, are dropped if set.
+		// Optional. Default value []string{}.
+		RedactJSONFields []string `yaml:"redact_json_fields"`
+
+		// RedactHeaders are the headers whose values are replaced with
+		// "[REDACTED]" in the record passed to RecordHandler.
+		// Optional. Default value []string{"Authorization", "Cookie", "Set-Cookie"}.
+		RedactHeaders []string `yaml:"redact_headers"`
+
+		// OnlyErrors calls the handlers only for responses with status code
+		// 400 or above.
+		// Optional. Default value false.
+		OnlyErrors bool `yaml:"only_errors"`
 	}
 
 	// BodyDumpHandler receives the request and response payload.
 	BodyDumpHandler func(nio.Context, []byte, []byte)
 
+	// BodyDumpRecord is a captured request and response.
+	BodyDumpRecord struct {
+		Status            int
+		RequestHeader     http.Header
+		RequestBody       []byte
+		RequestTruncated  bool // Request body exceeded MaxSize
+		ResponseHeader    http.Header
+		ResponseBody      []byte
+		ResponseTruncated bool // Response body exceeded MaxSize
+	}
+
+	// dumpBuffer captures up to limit bytes.
+	dumpBuffer struct {
+		bytes.Buffer
+		limit     int64
+		truncated bool
+	}
+
+	bodyDumpReader struct {
+		io.ReadCloser
+		capture *dumpBuffer
+	}
+
 	bodyDumpResponseWriter struct {
-		io.Writer
 		http.ResponseWriter
+		capture *dumpBuffer
+		config  *BodyDumpConfig
+		decided bool
+		enabled bool
 	}
 )
 
+const redacted = "[REDACTED]"
+
 var (
 	// DefaultBodyDumpConfig is the default BodyDump middleware config.
 	DefaultBodyDumpConfig = BodyDumpConfig{
-		Skipper: nio.DefaultSkipper,
+		Skipper:       nio.DefaultSkipper,
+		MaxSize:       "64KB",
+		RedactHeaders: []string{nio.HeaderAuthorization, nio.HeaderCookie, nio.HeaderSetCookie},
 	}
 )
 
 // BodyDump returns a BodyDump middleware.
 //
-// BodyLimit middleware captures the request and response payload and calls the
-// registered handler.
+// BodyDump middleware captures the request and response payload and calls the
+// registered handler. Payloads are captured while they are streamed, up to
+// MaxSize, so large uploads and Server-Sent Events pass through unaffected. The
+// request body is captured as the handler reads it, the part the handler
+// doesn't read isn't captured.
 func BodyDump(handler BodyDumpHandler) nio.MiddlewareFunc {
 	c := DefaultBodyDumpConfig
 	c.Handler = handler
@@ -52,12 +124,27 @@ func BodyDump(handler BodyDumpHandler) nio.MiddlewareFunc {
 // See: `BodyDump()`.
 func BodyDumpWithConfig(config BodyDumpConfig) nio.MiddlewareFunc {
 	// Defaults
-	if config.Handler == nil {
+	if config.Handler == nil && config.RecordHandler == nil {
 		panic("nio: body-dump middleware requires a handler function")
 	}
 	if config.Skipper == nil {
 		config.Skipper = DefaultBodyDumpConfig.Skipper
 	}
+	if config.MaxSize == "" {
+		config.MaxSize = DefaultBodyDumpConfig.MaxSize
+	}
+	if config.RedactHeaders == nil {
+		config.RedactHeaders = DefaultBodyDumpConfig.RedactHeaders
+	}
+	maxSize, err := nbytes.Parse(config.MaxSize)
+	if err != nil {
+		panic(fmt.Errorf("nio: invalid body-dump max-size=%s", config.MaxSize))
+	}
+	config.maxSize = maxSize
+	redactFields := map[string]bool{}
+	for _, f := range config.RedactJSONFields {
+		redactFields[strings.ToLower(f)] = true
+	}
 
 	return func(next nio.HandlerFunc) nio.HandlerFunc {
 		return func(c nio.Context) (err error) {
@@ -66,36 +153,103 @@ func BodyDumpWithConfig(config BodyDumpConfig) nio.MiddlewareFunc {
 			}
 
 			// Request
-			reqBody := []byte{}
-			if c.Request().Body != nil { // Read
-				reqBody, _ = ioutil.ReadAll(c.Request().Body)
+			req := c.Request()
+			var reqReader *bodyDumpReader
+			if req.Body != nil && config.captures(req.Header.Get(nio.HeaderContentType)) {
+				reqReader = &bodyDumpReader{ReadCloser: req.Body, capture: &dumpBuffer{limit: config.maxSize}}
+				req.Body = reqReader
 			}
-			c.Request().Body = ioutil.NopCloser(bytes.NewBuffer(reqBody)) // Reset
 
 			// Response
-			resBody := new(bytes.Buffer)
-			mw := io.MultiWriter(c.Response().Writer, resBody)
-			writer := &bodyDumpResponseWriter{Writer: mw, ResponseWriter: c.Response().Writer}
-			c.Response().Writer = writer
+			res := c.Response()
+			writer := &bodyDumpResponseWriter{
+				ResponseWriter: res.Writer,
+				capture:        &dumpBuffer{limit: config.maxSize},
+				config:         &config,
+			}
+			res.Writer = writer
 
 			if err = next(c); err != nil {
 				c.Error(err)
 			}
 
+			if config.OnlyErrors && res.Status < http.StatusBadRequest {
+				return
+			}
+
+			record := BodyDumpRecord{
+				Status:            res.Status,
+				RequestHeader:     redactHeaders(req.Header, config.RedactHeaders),
+				ResponseHeader:    redactHeaders(res.Header(), config.RedactHeaders),
+				ResponseBody:      writer.capture.Bytes(),
+				ResponseTruncated: writer.capture.truncated,
+			}
+			if reqReader != nil {
+				record.RequestBody = reqReader.capture.Bytes()
+				record.RequestTruncated = reqReader.capture.truncated
+			}
+			if len(redactFields) > 0 {
+				record.RequestBody = redactJSON(req.Header.Get(nio.HeaderContentType), record.RequestBody, redactFields)
+				record.ResponseBody = redactJSON(res.Header().Get(nio.HeaderContentType), record.ResponseBody, redactFields)
+			}
+			if record.RequestBody == nil {
+				record.RequestBody = []byte{}
+			}
+
 			// Callback
-			config.Handler(c, reqBody, resBody.Bytes())
+			if config.Handler != nil {
+				config.Handler(c, record.RequestBody, record.ResponseBody)
+			}
+			if config.RecordHandler != nil {
+				config.RecordHandler(c, record)
+			}
 
 			return
 		}
 	}
 }
 
+// captures reports whether payloads with the content type are captured.
+func (config *BodyDumpConfig) captures(contentType string) bool {
+	if len(config.ContentTypes) == 0 {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	for _, t := range config.ContentTypes {
+		if mediaType == t || strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *dumpBuffer) capture(p []byte) {
+	if room := b.limit - int64(b.Len()); int64(len(p)) > room {
+		p = p[:room]
+		b.truncated = true
+	}
+	b.Write(p)
+}
+
+func (r *bodyDumpReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	r.capture.capture(p[:n])
+	return
+}
+
 func (w *bodyDumpResponseWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
 func (w *bodyDumpResponseWriter) Write(b []byte) (int, error) {
-	return w.Writer.Write(b)
+	if !w.decided {
+		w.decided = true
+		w.enabled = w.config.captures(w.Header().Get(nio.HeaderContentType))
+	}
+	if w.enabled {
+		w.capture.capture(b)
+	}
+	return w.ResponseWriter.Write(b)
 }
 
 func (w *bodyDumpResponseWriter) Flush() {
@@ -109,3 +263,54 @@ func (w *bodyDumpResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 func (w *bodyDumpResponseWriter) CloseNotify() <-chan bool {
 	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
 }
+
+// redactHeaders returns a copy of the headers with the values of the names
+// redacted.
+func redactHeaders(h http.Header, names []string) http.Header {
+	h = h.Clone()
+	for _, name := range names {
+		if values, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			for i := range values {
+				values[i] = redacted
+			}
+		}
+	}
+	return h
+}
+
+// redactJSON redacts the fields of a JSON body, other bodies are returned as is.
+func redactJSON(contentType string, body []byte, fields map[string]bool) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if len(body) == 0 || mediaType != nio.MIMEApplicationJSON && !strings.HasSuffix(mediaType, "+json") {
+		return body
+	}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil // Might contain the fields
+	}
+	b, err := json.Marshal(redactValue(v, fields))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func redactValue(v interface{}, fields map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if fields[strings.ToLower(k)] {
+				v[k] = redacted
+			} else {
+				v[k] = redactValue(e, fields)
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = redactValue(e, fields)
+		}
+	}
+	return v
+}
@@ -89,3 +89,150 @@ func TestBodyDumpFails(t *testing.T) {
 		}
 	})
 }
+
+func TestBodyDumpMaxSize(t *testing.T) {
+	e := nio.New()
+	body := strings.Repeat("a", 100)
+	var record BodyDumpRecord
+	mw := BodyDumpWithConfig(BodyDumpConfig{
+		MaxSize: "10B",
+		RecordHandler: func(c nio.Context, r BodyDumpRecord) {
+			record = r
+		},
+	})
+
+	// Only the part of the body read by the handler is captured
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := func(c nio.Context) error {
+		b := make([]byte, 4)
+		c.Request().Body.Read(b)
+		return c.String(http.StatusOK, body)
+	}
+	assert.NoError(t, mw(h)(c))
+	assert.Equal(t, body, rec.Body.String())
+	assert.Equal(t, http.StatusOK, record.Status)
+	assert.Equal(t, "aaaa", string(record.RequestBody))
+	assert.False(t, record.RequestTruncated)
+	assert.Equal(t, strings.Repeat("a", 10), string(record.ResponseBody))
+	assert.True(t, record.ResponseTruncated)
+
+	// Whole body read
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c = e.NewContext(req, httptest.NewRecorder())
+	assert.NoError(t, mw(readBody)(c))
+	assert.Equal(t, strings.Repeat("a", 10), string(record.RequestBody))
+	assert.True(t, record.RequestTruncated)
+
+	// Within limit
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("abc"))
+	c = e.NewContext(req, httptest.NewRecorder())
+	assert.NoError(t, mw(func(c nio.Context) error {
+		ioutil.ReadAll(c.Request().Body)
+		return c.String(http.StatusOK, "def")
+	})(c))
+	assert.Equal(t, "abc", string(record.RequestBody))
+	assert.False(t, record.RequestTruncated)
+	assert.Equal(t, "def", string(record.ResponseBody))
+	assert.False(t, record.ResponseTruncated)
+
+	assert.Panics(t, func() {
+		BodyDumpWithConfig(BodyDumpConfig{MaxSize: "x", Handler: func(nio.Context, []byte, []byte) {}})
+	})
+}
+
+func TestBodyDumpContentTypes(t *testing.T) {
+	e := nio.New()
+	var reqBody, resBody []byte
+	mw := BodyDumpWithConfig(BodyDumpConfig{
+		ContentTypes: []string{nio.MIMEApplicationJSON, "text/"},
+		Handler: func(c nio.Context, req, res []byte) {
+			reqBody, resBody = req, res
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("binary"))
+	req.Header.Set(nio.HeaderContentType, nio.MIMEOctetStream)
+	c := e.NewContext(req, httptest.NewRecorder())
+	assert.NoError(t, mw(func(c nio.Context) error {
+		ioutil.ReadAll(c.Request().Body)
+		return c.String(http.StatusOK, "text")
+	})(c))
+	assert.Empty(t, reqBody)
+	assert.Equal(t, "text", string(resBody))
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":1}`))
+	req.Header.Set(nio.HeaderContentType, nio.MIMEApplicationJSONCharsetUTF8)
+	c = e.NewContext(req, httptest.NewRecorder())
+	assert.NoError(t, mw(func(c nio.Context) error {
+		ioutil.ReadAll(c.Request().Body)
+		return c.Blob(http.StatusOK, nio.MIMEOctetStream, []byte("binary"))
+	})(c))
+	assert.Equal(t, `{"a":1}`, string(reqBody))
+	assert.Empty(t, resBody)
+}
+
+func TestBodyDumpRedact(t *testing.T) {
+	e := nio.New()
+	var record BodyDumpRecord
+	mw := BodyDumpWithConfig(BodyDumpConfig{
+		RedactJSONFields: []string{"password", "Token"},
+		RecordHandler: func(c nio.Context, r BodyDumpRecord) {
+			record = r
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"user":"jon","Password":"secret","n":1.50}`))
+	req.Header.Set(nio.HeaderContentType, nio.MIMEApplicationJSON)
+	req.Header.Set(nio.HeaderAuthorization, "Bearer secret")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	assert.NoError(t, mw(func(c nio.Context) error {
+		ioutil.ReadAll(c.Request().Body)
+		c.SetCookie(&http.Cookie{Name: "session", Value: "secret"})
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"items": []map[string]string{{"token": "secret", "id": "1"}},
+		})
+	})(c))
+	assert.JSONEq(t, `{"user":"jon","Password":"[REDACTED]","n":1.50}`, string(record.RequestBody))
+	assert.JSONEq(t, `{"items":[{"token":"[REDACTED]","id":"1"}]}`, string(record.ResponseBody))
+	assert.Equal(t, "[REDACTED]", record.RequestHeader.Get(nio.HeaderAuthorization))
+	assert.Equal(t, "[REDACTED]", record.ResponseHeader.Get(nio.HeaderSetCookie))
+	assert.Equal(t, nio.MIMEApplicationJSON, record.RequestHeader.Get(nio.HeaderContentType))
+
+	// Original request and response are untouched
+	assert.Equal(t, "Bearer secret", req.Header.Get(nio.HeaderAuthorization))
+	assert.Contains(t, rec.Body.String(), `"token":"secret"`)
+	assert.Contains(t, rec.Header().Get(nio.HeaderSetCookie), "secret")
+
+	// Unparsable JSON is dropped
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"password":"sec`))
+	req.Header.Set(nio.HeaderContentType, nio.MIMEApplicationJSON)
+	c = e.NewContext(req, httptest.NewRecorder())
+	assert.NoError(t, mw(readBody)(c))
+	assert.Empty(t, record.RequestBody)
+}
+
+func TestBodyDumpOnlyErrors(t *testing.T) {
+	e := nio.New()
+	calls := 0
+	mw := BodyDumpWithConfig(BodyDumpConfig{
+		OnlyErrors: true,
+		Handler: func(nio.Context, []byte, []byte) {
+			calls++
+		},
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	assert.NoError(t, mw(func(c nio.Context) error { return c.NoContent(http.StatusOK) })(c))
+	assert.Equal(t, 0, calls)
+
+	c = e.NewContext(req, httptest.NewRecorder())
+	assert.Error(t, mw(func(c nio.Context) error { return nio.ErrNotFound })(c))
+	assert.Equal(t, 1, calls)
+}
+
+func readBody(c nio.Context) error {
+	_, err := ioutil.ReadAll(c.Request().Body)
+	return err
+}
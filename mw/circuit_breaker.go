@@ -0,0 +1,313 @@
+package mw
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-nio/nio"
+)
+
+type (
+	// CircuitBreakerConfig defines the config for CircuitBreaker middleware.
+	CircuitBreakerConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper nio.Skipper
+
+		// KeyFunc returns the key of the circuit of the request. Keys must be of
+		// a bounded set.
+		// Optional. Default value returns the host, method and path of the
+		// matched route.
+		KeyFunc func(c nio.Context) string
+
+		// IsFailure reports whether the request failed.
+		// Optional. Default value reports errors and responses with status code
+		// 500 or above.
+		IsFailure func(c nio.Context, err error) bool
+
+		// Window is the duration the error rate is computed for.
+		// Optional. Default value 10 seconds.
+		Window time.Duration `yaml:"window"`
+
+		// MinRequests is the minimum number of requests in the window for the
+		// error rate to trip the circuit.
+		// Optional. Default value 20.
+		MinRequests int `yaml:"min_requests"`
+
+		// ErrorRate trips the circuit when the rate of failed requests in the
+		// window reaches it. A negative value disables it.
+		// Optional. Default value 0.5.
+		ErrorRate float64 `yaml:"error_rate"`
+
+		// ConsecutiveFailures trips the circuit when as many requests fail in a
+		// row. A negative value disables it.
+		// Optional. Default value 5.
+		ConsecutiveFailures int `yaml:"consecutive_failures"`
+
+		// OpenTimeout is the duration the circuit stays open before it
+		// half-opens to probe.
+		// Optional. Default value 30 seconds.
+		OpenTimeout time.Duration `yaml:"open_timeout"`
+
+		// HalfOpenRequests is the number of probe requests allowed while the
+		// circuit is half-open. The circuit closes when they all succeed and
+		// opens again when one fails.
+		// Optional. Default value 1.
+		HalfOpenRequests int `yaml:"half_open_requests"`
+
+		// Fallback is called for the requests rejected while the circuit is open,
+		// after the Retry-After header is set.
+		// Optional. Default value sends "503 - Service Unavailable" response.
+		Fallback nio.HandlerFunc
+
+		// OnStateChange is called when the circuit of a key changes state.
+		// Optional. Default value nil.
+		OnStateChange func(key string, from, to CircuitState)
+	}
+
+	// CircuitState is the state of a circuit.
+	CircuitState int
+
+	// circuit tracks the requests of a key.
+	circuit struct {
+		mu          sync.Mutex
+		config      *CircuitBreakerConfig
+		key         string
+		state       CircuitState
+		openedAt    time.Time
+		windowStart time.Time
+		requests    int
+		failures    int
+		consecutive int
+		probes      int // Probes allowed while half-open
+		successes   int // Probes succeeded while half-open
+	}
+)
+
+// Circuit states
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+var (
+	// DefaultCircuitBreakerConfig is the default CircuitBreaker middleware
+	// config.
+	DefaultCircuitBreakerConfig = CircuitBreakerConfig{
+		Skipper:             nio.DefaultSkipper,
+		KeyFunc:             routeKey,
+		IsFailure:           isServerFailure,
+		Window:              10 * time.Second,
+		MinRequests:         20,
+		ErrorRate:           0.5,
+		ConsecutiveFailures: 5,
+		OpenTimeout:         30 * time.Second,
+		HalfOpenRequests:    1,
+		Fallback: func(c nio.Context) error {
+			return nio.ErrServiceUnavailable
+		},
+	}
+)
+
+// CircuitBreaker returns a CircuitBreaker middleware.
+//
+// CircuitBreaker middleware tracks the failures of the requests of each route.
+// When the failures reach the error rate or the consecutive failures threshold
+// the circuit opens and requests are served by the fallback, sparing a failing
+// downstream dependency. After the open timeout the circuit half-opens and lets
+// probe requests through to decide whether to close or open again.
+func CircuitBreaker() nio.MiddlewareFunc {
+	return CircuitBreakerWithConfig(DefaultCircuitBreakerConfig)
+}
+
+// CircuitBreakerWithConfig returns a CircuitBreaker middleware with config.
+// See: `CircuitBreaker()`.
+func CircuitBreakerWithConfig(config CircuitBreakerConfig) nio.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultCircuitBreakerConfig.Skipper
+	}
+	if config.KeyFunc == nil {
+		config.KeyFunc = DefaultCircuitBreakerConfig.KeyFunc
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = DefaultCircuitBreakerConfig.IsFailure
+	}
+	if config.Window == 0 {
+		config.Window = DefaultCircuitBreakerConfig.Window
+	}
+	if config.MinRequests == 0 {
+		config.MinRequests = DefaultCircuitBreakerConfig.MinRequests
+	}
+	if config.ErrorRate == 0 {
+		config.ErrorRate = DefaultCircuitBreakerConfig.ErrorRate
+	}
+	if config.ConsecutiveFailures == 0 {
+		config.ConsecutiveFailures = DefaultCircuitBreakerConfig.ConsecutiveFailures
+	}
+	if config.OpenTimeout == 0 {
+		config.OpenTimeout = DefaultCircuitBreakerConfig.OpenTimeout
+	}
+	if config.HalfOpenRequests == 0 {
+		config.HalfOpenRequests = DefaultCircuitBreakerConfig.HalfOpenRequests
+	}
+	if config.Fallback == nil {
+		config.Fallback = DefaultCircuitBreakerConfig.Fallback
+	}
+
+	var mu sync.Mutex
+	circuits := map[string]*circuit{}
+	circuitOf := func(key string) *circuit {
+		mu.Lock()
+		defer mu.Unlock()
+		cb, ok := circuits[key]
+		if !ok {
+			cb = &circuit{config: &config, key: key, windowStart: time.Now()}
+			circuits[key] = cb
+		}
+		return cb
+	}
+
+	return func(next nio.HandlerFunc) nio.HandlerFunc {
+		return func(c nio.Context) (err error) {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			cb := circuitOf(config.KeyFunc(c))
+			if retryAfter, ok := cb.allow(time.Now()); !ok {
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+				c.Response().Header().Set(nio.HeaderRetryAfter, strconv.Itoa(seconds))
+				return config.Fallback(c)
+			}
+			defer func() {
+				if r := recover(); r != nil {
+					cb.record(time.Now(), true)
+					panic(r)
+				}
+			}()
+			err = next(c)
+			cb.record(time.Now(), config.IsFailure(c, err))
+			return
+		}
+	}
+}
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// allow reports whether a request may pass, or the duration until the circuit
+// half-opens.
+func (cb *circuit) allow(now time.Time) (time.Duration, bool) {
+	cb.mu.Lock()
+	from := cb.state
+	if cb.state == CircuitOpen {
+		if wait := cb.config.OpenTimeout - now.Sub(cb.openedAt); wait > 0 {
+			cb.mu.Unlock()
+			return wait, false
+		}
+		cb.state = CircuitHalfOpen
+		cb.probes = 0
+		cb.successes = 0
+	}
+	ok := true
+	if cb.state == CircuitHalfOpen {
+		ok = cb.probes < cb.config.HalfOpenRequests
+		if ok {
+			cb.probes++
+		}
+	}
+	to := cb.state
+	cb.mu.Unlock()
+
+	cb.changed(from, to)
+	if !ok {
+		return time.Second, false // Until the probes complete
+	}
+	return 0, true
+}
+
+// record records the result of a request.
+func (cb *circuit) record(now time.Time, failed bool) {
+	cb.mu.Lock()
+	from := cb.state
+	switch cb.state {
+	case CircuitClosed:
+		if now.Sub(cb.windowStart) > cb.config.Window {
+			cb.requests, cb.failures = 0, 0
+			cb.windowStart = now
+		}
+		cb.requests++
+		if failed {
+			cb.failures++
+			cb.consecutive++
+		} else {
+			cb.consecutive = 0
+		}
+		if cb.tripped() {
+			cb.open(now)
+		}
+	case CircuitHalfOpen:
+		if failed {
+			cb.open(now)
+		} else if cb.successes++; cb.successes >= cb.config.HalfOpenRequests {
+			cb.state = CircuitClosed
+			cb.requests, cb.failures, cb.consecutive = 0, 0, 0
+			cb.windowStart = now
+		}
+	}
+	to := cb.state
+	cb.mu.Unlock()
+
+	cb.changed(from, to)
+}
+
+func (cb *circuit) tripped() bool {
+	if n := cb.config.ConsecutiveFailures; n > 0 && cb.consecutive >= n {
+		return true
+	}
+	rate := cb.config.ErrorRate
+	return rate > 0 && cb.requests >= cb.config.MinRequests &&
+		float64(cb.failures)/float64(cb.requests) >= rate
+}
+
+func (cb *circuit) open(now time.Time) {
+	cb.state = CircuitOpen
+	cb.openedAt = now
+}
+
+func (cb *circuit) changed(from, to CircuitState) {
+	if from != to && cb.config.OnStateChange != nil {
+		cb.config.OnStateChange(cb.key, from, to)
+	}
+}
+
+// routeKey returns the host, method and path of the matched route.
+func routeKey(c nio.Context) string {
+	r := c.Route()
+	if r == nil {
+		return ""
+	}
+	return r.Host + " " + r.Method + " " + r.Path
+}
+
+func isServerFailure(c nio.Context, err error) bool {
+	if err != nil {
+		he, ok := err.(*nio.HTTPError)
+		return !ok || he.Code >= http.StatusInternalServerError
+	}
+	return c.Response().Status >= http.StatusInternalServerError
+}
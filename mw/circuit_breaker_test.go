@@ -0,0 +1,127 @@
+package mw
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-nio/nio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	e := nio.New()
+	fail := true
+	calls := 0
+	var changes []string
+	mw := CircuitBreakerWithConfig(CircuitBreakerConfig{
+		ConsecutiveFailures: 2,
+		OpenTimeout:         20 * time.Millisecond,
+		Fallback: func(c nio.Context) error {
+			return c.String(http.StatusServiceUnavailable, "fallback")
+		},
+		OnStateChange: func(key string, from, to CircuitState) {
+			changes = append(changes, key+": "+from.String()+" -> "+to.String())
+		},
+	})
+	e.GET("/users/:id", func(c nio.Context) error {
+		calls++
+		if fail {
+			return errors.New("downstream")
+		}
+		return c.NoContent(http.StatusOK)
+	}, mw)
+	e.GET("/health", func(c nio.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, mw)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, request("/users/1").Code)
+	assert.Equal(t, http.StatusInternalServerError, request("/users/2").Code)
+
+	// Open
+	rec := request("/users/3")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "fallback", rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get(nio.HeaderRetryAfter))
+	assert.Equal(t, 2, calls)
+
+	// Other routes have their own circuit
+	assert.Equal(t, http.StatusOK, request("/health").Code)
+
+	// Half-open probe fails
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, http.StatusInternalServerError, request("/users/1").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, request("/users/1").Code)
+
+	// Half-open probe succeeds
+	time.Sleep(30 * time.Millisecond)
+	fail = false
+	assert.Equal(t, http.StatusOK, request("/users/1").Code)
+	assert.Equal(t, http.StatusOK, request("/users/1").Code)
+
+	assert.Equal(t, []string{
+		" GET /users/:id: closed -> open",
+		" GET /users/:id: open -> half-open",
+		" GET /users/:id: half-open -> open",
+		" GET /users/:id: open -> half-open",
+		" GET /users/:id: half-open -> closed",
+	}, changes)
+}
+
+func TestCircuitBreakerErrorRate(t *testing.T) {
+	config := DefaultCircuitBreakerConfig
+	config.MinRequests = 4
+	config.ConsecutiveFailures = -1
+	now := time.Now()
+	cb := &circuit{config: &config, windowStart: now}
+
+	// Below the minimum requests
+	cb.record(now, true)
+	cb.record(now, true)
+	cb.record(now, true)
+	assert.Equal(t, CircuitClosed, cb.state)
+
+	// Window expired
+	now = now.Add(config.Window + time.Second)
+	cb.record(now, false)
+	cb.record(now, true)
+	cb.record(now, false)
+	assert.Equal(t, CircuitClosed, cb.state)
+	cb.record(now, true)
+	assert.Equal(t, CircuitOpen, cb.state)
+
+	wait, ok := cb.allow(now.Add(time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, config.OpenTimeout-time.Second, wait)
+
+	// Only the allowed probes pass while half-open
+	now = now.Add(config.OpenTimeout)
+	_, ok = cb.allow(now)
+	assert.True(t, ok)
+	assert.Equal(t, CircuitHalfOpen, cb.state)
+	_, ok = cb.allow(now)
+	assert.False(t, ok)
+	cb.record(now, false)
+	assert.Equal(t, CircuitClosed, cb.state)
+}
+
+func TestCircuitBreakerFailure(t *testing.T) {
+	e := nio.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	assert.False(t, isServerFailure(c, nil))
+	assert.False(t, isServerFailure(c, nio.ErrNotFound))
+	assert.True(t, isServerFailure(c, nio.ErrServiceUnavailable))
+	assert.True(t, isServerFailure(c, errors.New("error")))
+	c.Response().WriteHeader(http.StatusBadGateway)
+	assert.True(t, isServerFailure(c, nil))
+	assert.Equal(t, "", routeKey(c))
+	assert.Equal(t, "unknown", CircuitState(-1).String())
+}
@@ -0,0 +1,213 @@
+package mw
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-nio/nio"
+)
+
+type (
+	// ConcurrencyLimitConfig defines the config for ConcurrencyLimit middleware.
+	ConcurrencyLimitConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper nio.Skipper
+
+		// Limit is the maximum number of requests in flight.
+		// Optional. Default value 100.
+		Limit int `yaml:"limit"`
+
+		// QueueSize is the maximum number of requests waiting for a request in
+		// flight to complete. Requests are shed when the queue is full.
+		// Optional. Default value 0, requests are shed at the limit.
+		QueueSize int `yaml:"queue_size"`
+
+		// QueueTimeout is the maximum duration a request waits in the queue.
+		// Optional. Default value 1 second.
+		QueueTimeout time.Duration `yaml:"queue_timeout"`
+
+		// KeyFunc returns the key of the request, each key has its own limit,
+		// e.g. `c.Path()` for a limit per route. Keys must be of a bounded set.
+		// Optional. Default value nil, all requests share the limit.
+		KeyFunc func(c nio.Context) string
+
+		// LatencyTarget enables the adaptive limit. The limit decreases while
+		// requests take longer than the target and increases back up to Limit
+		// while they complete within the target.
+		// Optional. Default value 0, the limit is fixed.
+		LatencyTarget time.Duration `yaml:"latency_target"`
+
+		// MinLimit is the minimum of the adaptive limit.
+		// Optional. Default value 1.
+		MinLimit int `yaml:"min_limit"`
+
+		// RetryAfter is the duration sent in the Retry-After header of shed
+		// requests, rounded up to seconds.
+		// Optional. Default value 1 second.
+		RetryAfter time.Duration `yaml:"retry_after"`
+
+		// ShedHandler is called for shed requests, after the Retry-After header
+		// is set.
+		// Optional. Default value sends "503 - Service Unavailable" response.
+		ShedHandler nio.HandlerFunc
+	}
+
+	// concurrencyLimiter limits the requests in flight of a key.
+	concurrencyLimiter struct {
+		mu       sync.Mutex
+		config   *ConcurrencyLimitConfig
+		limit    float64
+		inflight int
+		waiters  []chan struct{}
+	}
+)
+
+var (
+	// DefaultConcurrencyLimitConfig is the default ConcurrencyLimit middleware
+	// config.
+	DefaultConcurrencyLimitConfig = ConcurrencyLimitConfig{
+		Skipper:      nio.DefaultSkipper,
+		Limit:        100,
+		QueueTimeout: time.Second,
+		MinLimit:     1,
+		RetryAfter:   time.Second,
+		ShedHandler: func(c nio.Context) error {
+			return nio.ErrServiceUnavailable
+		},
+	}
+)
+
+// ConcurrencyLimit returns a ConcurrencyLimit middleware, which limits the
+// requests in flight to limit and sheds the other requests with
+// "503 - Service Unavailable" response and the Retry-After header, so a slow
+// dependency doesn't pile up requests.
+func ConcurrencyLimit(limit int) nio.MiddlewareFunc {
+	c := DefaultConcurrencyLimitConfig
+	c.Limit = limit
+	return ConcurrencyLimitWithConfig(c)
+}
+
+// ConcurrencyLimitWithConfig returns a ConcurrencyLimit middleware with config.
+// See: `ConcurrencyLimit()`.
+func ConcurrencyLimitWithConfig(config ConcurrencyLimitConfig) nio.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultConcurrencyLimitConfig.Skipper
+	}
+	if config.Limit == 0 {
+		config.Limit = DefaultConcurrencyLimitConfig.Limit
+	}
+	if config.QueueTimeout == 0 {
+		config.QueueTimeout = DefaultConcurrencyLimitConfig.QueueTimeout
+	}
+	if config.MinLimit == 0 {
+		config.MinLimit = DefaultConcurrencyLimitConfig.MinLimit
+	}
+	if config.RetryAfter == 0 {
+		config.RetryAfter = DefaultConcurrencyLimitConfig.RetryAfter
+	}
+	if config.ShedHandler == nil {
+		config.ShedHandler = DefaultConcurrencyLimitConfig.ShedHandler
+	}
+	if config.Limit < 0 || config.QueueSize < 0 || config.MinLimit < 0 || config.MinLimit > config.Limit {
+		panic("nio: invalid concurrency-limit limits")
+	}
+	retryAfter := strconv.Itoa(int(math.Ceil(config.RetryAfter.Seconds())))
+
+	var mu sync.Mutex
+	limiters := map[string]*concurrencyLimiter{}
+	limiter := func(key string) *concurrencyLimiter {
+		mu.Lock()
+		defer mu.Unlock()
+		l, ok := limiters[key]
+		if !ok {
+			l = &concurrencyLimiter{config: &config, limit: float64(config.Limit)}
+			limiters[key] = l
+		}
+		return l
+	}
+
+	return func(next nio.HandlerFunc) nio.HandlerFunc {
+		return func(c nio.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			key := ""
+			if config.KeyFunc != nil {
+				key = config.KeyFunc(c)
+			}
+			l := limiter(key)
+			if !l.acquire(c) {
+				c.Response().Header().Set(nio.HeaderRetryAfter, retryAfter)
+				return config.ShedHandler(c)
+			}
+			start := time.Now()
+			defer func() {
+				l.release(time.Since(start))
+			}()
+			return next(c)
+		}
+	}
+}
+
+// acquire takes a slot, waiting in the queue if the limit is reached. It
+// returns false if the request is shed.
+func (l *concurrencyLimiter) acquire(c nio.Context) bool {
+	l.mu.Lock()
+	if float64(l.inflight) < math.Floor(l.limit) {
+		l.inflight++
+		l.mu.Unlock()
+		return true
+	}
+	if len(l.waiters) >= l.config.QueueSize {
+		l.mu.Unlock()
+		return false
+	}
+	ready := make(chan struct{})
+	l.waiters = append(l.waiters, ready)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.config.QueueTimeout)
+	defer timer.Stop()
+	select {
+	case <-ready:
+		return true
+	case <-timer.C:
+	case <-c.Request().Context().Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, w := range l.waiters {
+		if w == ready {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			return false
+		}
+	}
+	// The slot was handed over concurrently
+	return true
+}
+
+// release frees the slot of a request which took latency, handing it over to
+// the first waiting request.
+func (l *concurrencyLimiter) release(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if target := l.config.LatencyTarget; target > 0 {
+		if latency > target {
+			l.limit = math.Max(float64(l.config.MinLimit), l.limit*0.9)
+		} else {
+			l.limit = math.Min(float64(l.config.Limit), l.limit+1/l.limit)
+		}
+	}
+	if len(l.waiters) > 0 && float64(l.inflight) <= math.Floor(l.limit) {
+		ready := l.waiters[0]
+		l.waiters = l.waiters[1:]
+		close(ready) // The slot stays in flight
+		return
+	}
+	l.inflight--
+}
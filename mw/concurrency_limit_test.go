@@ -0,0 +1,155 @@
+package mw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-nio/nio"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingHandler blocks the requests until released, signalling on started
+// when a request starts.
+func blockingHandler() (h nio.HandlerFunc, started chan struct{}, release chan struct{}) {
+	started, release = make(chan struct{}, 10), make(chan struct{})
+	h = func(c nio.Context) error {
+		started <- struct{}{}
+		<-release
+		return c.NoContent(http.StatusOK)
+	}
+	return
+}
+
+func serveAsync(wg *sync.WaitGroup, e *nio.Nio, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}()
+	return rec
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	e := nio.New()
+	h, started, release := blockingHandler()
+	e.GET("/", h, ConcurrencyLimit(1))
+
+	var wg sync.WaitGroup
+	first := serveAsync(&wg, e, "/")
+	<-started
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get(nio.HeaderRetryAfter))
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestConcurrencyLimitQueue(t *testing.T) {
+	e := nio.New()
+	h, started, release := blockingHandler()
+	e.GET("/", h, ConcurrencyLimitWithConfig(ConcurrencyLimitConfig{
+		Limit:        1,
+		QueueSize:    1,
+		QueueTimeout: time.Minute,
+		RetryAfter:   1500 * time.Millisecond,
+	}))
+
+	var wg sync.WaitGroup
+	first := serveAsync(&wg, e, "/")
+	<-started
+
+	// Of two more requests one is queued and the other shed
+	done := make(chan *httptest.ResponseRecorder, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			done <- rec
+		}()
+	}
+	shed := <-done
+	assert.Equal(t, http.StatusServiceUnavailable, shed.Code)
+	assert.Equal(t, "2", shed.Header().Get(nio.HeaderRetryAfter))
+
+	release <- struct{}{}
+	<-started // Queued request got the slot
+	close(release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, (<-done).Code)
+}
+
+func TestConcurrencyLimitQueueTimeout(t *testing.T) {
+	e := nio.New()
+	h, started, release := blockingHandler()
+	e.GET("/", h, ConcurrencyLimitWithConfig(ConcurrencyLimitConfig{
+		Limit:        1,
+		QueueSize:    1,
+		QueueTimeout: 10 * time.Millisecond,
+	}))
+
+	var wg sync.WaitGroup
+	serveAsync(&wg, e, "/")
+	<-started
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	close(release)
+	wg.Wait()
+}
+
+func TestConcurrencyLimitKey(t *testing.T) {
+	e := nio.New()
+	h, started, release := blockingHandler()
+	mw := ConcurrencyLimitWithConfig(ConcurrencyLimitConfig{
+		Limit: 1,
+		KeyFunc: func(c nio.Context) string {
+			return c.Path()
+		},
+	})
+	e.GET("/a", h, mw)
+	e.GET("/b", h, mw)
+
+	var wg sync.WaitGroup
+	a := serveAsync(&wg, e, "/a")
+	b := serveAsync(&wg, e, "/b")
+	<-started
+	<-started
+	close(release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, a.Code)
+	assert.Equal(t, http.StatusOK, b.Code)
+}
+
+func TestConcurrencyLimitAdaptive(t *testing.T) {
+	config := ConcurrencyLimitConfig{Limit: 10, MinLimit: 2, LatencyTarget: 100 * time.Millisecond}
+	l := &concurrencyLimiter{config: &config, limit: 10}
+	for i := 0; i < 100; i++ {
+		l.inflight++
+		l.release(time.Second)
+	}
+	assert.Equal(t, 2.0, l.limit)
+
+	for i := 0; i < 1000; i++ {
+		l.inflight++
+		l.release(time.Millisecond)
+	}
+	assert.Equal(t, 10.0, l.limit)
+	assert.Equal(t, 0, l.inflight)
+
+	assert.Panics(t, func() {
+		ConcurrencyLimitWithConfig(ConcurrencyLimitConfig{Limit: 1, MinLimit: 2})
+	})
+}
@@ -19,8 +19,10 @@ type (
 		AllowOrigins []string `yaml:"allow_origins"`
 
 		// AllowMethods defines a list methods allowed when accessing the resource.
-		// This is used in response to a preflight request.
-		// Optional. Default value DefaultCORSConfig.AllowMethods.
+		// This is used in response to a preflight request. If empty, the methods
+		// registered for the requested path are used, see `Context#AllowedMethods()`.
+		// Optional. Default value DefaultCORSConfig.AllowMethods for paths
+		// without routes.
 		AllowMethods []string `yaml:"allow_methods"`
 
 		// AllowHeaders defines a list of request headers that can be used when
@@ -72,7 +74,8 @@ func CORSWithConfig(config CORSConfig) nio.MiddlewareFunc {
 	if len(config.AllowOrigins) == 0 {
 		config.AllowOrigins = DefaultCORSConfig.AllowOrigins
 	}
-	if len(config.AllowMethods) == 0 {
+	routeMethods := len(config.AllowMethods) == 0
+	if routeMethods {
 		config.AllowMethods = DefaultCORSConfig.AllowMethods
 	}
 
@@ -122,7 +125,11 @@ func CORSWithConfig(config CORSConfig) nio.MiddlewareFunc {
 			res.Header().Add(nio.HeaderVary, nio.HeaderAccessControlRequestMethod)
 			res.Header().Add(nio.HeaderVary, nio.HeaderAccessControlRequestHeaders)
 			res.Header().Set(nio.HeaderAccessControlAllowOrigin, allowOrigin)
-			res.Header().Set(nio.HeaderAccessControlAllowMethods, allowMethods)
+			if allowed := c.AllowedMethods(); routeMethods && len(allowed) > 0 {
+				res.Header().Set(nio.HeaderAccessControlAllowMethods, strings.Join(allowed, ","))
+			} else {
+				res.Header().Set(nio.HeaderAccessControlAllowMethods, allowMethods)
+			}
 			if config.AllowCredentials {
 				res.Header().Set(nio.HeaderAccessControlAllowCredentials, "true")
 			}
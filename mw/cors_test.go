@@ -3,6 +3,7 @@ package mw
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-nio/nio"
@@ -67,3 +68,25 @@ func TestCORS(t *testing.T) {
 	assert.Equal(t, "true", rec.Header().Get(nio.HeaderAccessControlAllowCredentials))
 	assert.Equal(t, "3600", rec.Header().Get(nio.HeaderAccessControlMaxAge))
 }
+
+func TestCORSRouteMethods(t *testing.T) {
+	e := nio.New()
+	e.Use(CORSWithConfig(CORSConfig{}))
+	h := func(c nio.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/users", h)
+	e.POST("/users", h)
+
+	req := httptest.NewRequest(http.MethodOptions, "/users", nil)
+	req.Header.Set(nio.HeaderOrigin, "localhost")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "GET,HEAD,POST", rec.Header().Get(nio.HeaderAccessControlAllowMethods))
+
+	// Default methods for paths without routes
+	req = httptest.NewRequest(http.MethodOptions, "/unknown", nil)
+	req.Header.Set(nio.HeaderOrigin, "localhost")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, strings.Join(DefaultCORSConfig.AllowMethods, ","), rec.Header().Get(nio.HeaderAccessControlAllowMethods))
+}
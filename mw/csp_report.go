@@ -0,0 +1,315 @@
+package mw
+
+import (
+	"container/list"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-nio/nio"
+)
+
+type (
+	// CSPReportConfig defines the config for the CSPReport handler.
+	CSPReportConfig struct {
+		// Limit is the maximum allowed size for a report body, see `BodyLimit()`.
+		// Optional. Default value "64KB".
+		Limit string `yaml:"limit"`
+
+		// DedupWindow is the duration in which identical reports are forwarded
+		// to the sink only once. Browsers send a report for every violation,
+		// which is often the same one many times. A negative value disables
+		// de-duplication.
+		// Optional. Default value 1 minute.
+		DedupWindow time.Duration `yaml:"dedup_window"`
+
+		// DedupMaxEntries is the maximum number of reports remembered for
+		// de-duplication, the oldest ones are forgotten first. It bounds the
+		// memory used by clients sending distinct reports. A negative value
+		// removes the bound.
+		// Optional. Default value 10000.
+		DedupMaxEntries int `yaml:"dedup_max_entries"`
+
+		// Sink receives the parsed reports.
+		// Optional. Default value logs the reports with the nio logger.
+		Sink func(c nio.Context, v CSPViolation)
+	}
+
+	// CSPViolation is a Content Security Policy violation report, either sent
+	// with the `report-uri` directive as `application/csp-report` or with the
+	// Reporting API as `application/reports+json`.
+	CSPViolation struct {
+		// Type of the report, "csp-violation" for violations. The Reporting API
+		// also delivers other types, e.g. "deprecation", which only have Body.
+		Type               string          `json:"type"`
+		UserAgent          string          `json:"user_agent,omitempty"`
+		DocumentURL        string          `json:"document_url,omitempty"`
+		Referrer           string          `json:"referrer,omitempty"`
+		BlockedURL         string          `json:"blocked_url,omitempty"`
+		EffectiveDirective string          `json:"effective_directive,omitempty"`
+		OriginalPolicy     string          `json:"original_policy,omitempty"`
+		Disposition        string          `json:"disposition,omitempty"`
+		SourceFile         string          `json:"source_file,omitempty"`
+		Sample             string          `json:"sample,omitempty"`
+		LineNumber         int             `json:"line_number,omitempty"`
+		ColumnNumber       int             `json:"column_number,omitempty"`
+		StatusCode         int             `json:"status_code,omitempty"`
+		Body               json.RawMessage `json:"body,omitempty"` // Report as sent
+	}
+
+	// legacyCSPReport is the `application/csp-report` payload.
+	legacyCSPReport struct {
+		Report struct {
+			DocumentURI        string `json:"document-uri"`
+			Referrer           string `json:"referrer"`
+			BlockedURI         string `json:"blocked-uri"`
+			ViolatedDirective  string `json:"violated-directive"`
+			EffectiveDirective string `json:"effective-directive"`
+			OriginalPolicy     string `json:"original-policy"`
+			Disposition        string `json:"disposition"`
+			SourceFile         string `json:"source-file"`
+			ScriptSample       string `json:"script-sample"`
+			LineNumber         int    `json:"line-number"`
+			ColumnNumber       int    `json:"column-number"`
+			StatusCode         int    `json:"status-code"`
+		} `json:"csp-report"`
+	}
+
+	// reportingAPIReport is a report of the `application/reports+json`
+	// payload.
+	reportingAPIReport struct {
+		Type      string          `json:"type"`
+		URL       string          `json:"url"`
+		UserAgent string          `json:"user_agent"`
+		Body      json.RawMessage `json:"body"`
+	}
+
+	// reportingAPIViolation is the body of a "csp-violation" report.
+	reportingAPIViolation struct {
+		DocumentURL        string `json:"documentURL"`
+		Referrer           string `json:"referrer"`
+		BlockedURL         string `json:"blockedURL"`
+		EffectiveDirective string `json:"effectiveDirective"`
+		OriginalPolicy     string `json:"originalPolicy"`
+		Disposition        string `json:"disposition"`
+		SourceFile         string `json:"sourceFile"`
+		Sample             string `json:"sample"`
+		LineNumber         int    `json:"lineNumber"`
+		ColumnNumber       int    `json:"columnNumber"`
+		StatusCode         int    `json:"statusCode"`
+	}
+
+	// reportDeduper remembers recently seen reports.
+	reportDeduper struct {
+		mu      sync.Mutex
+		window  time.Duration
+		max     int
+		seen    map[string]*list.Element
+		entries *list.List // *seenReport, oldest first
+	}
+
+	seenReport struct {
+		key string
+		at  time.Time
+	}
+)
+
+// MIME types of reports
+const (
+	MIMEApplicationCSPReport   = "application/csp-report"
+	MIMEApplicationReportsJSON = "application/reports+json"
+)
+
+var (
+	// DefaultCSPReportConfig is the default CSPReport handler config.
+	DefaultCSPReportConfig = CSPReportConfig{
+		Limit:           "64KB",
+		DedupWindow:     time.Minute,
+		DedupMaxEntries: 10000,
+		Sink:            logCSPViolation,
+	}
+)
+
+// CSPReport returns a handler receiving Content Security Policy violation
+// reports, to be registered at the path of the `report-uri` or `report-to`
+// directive:
+//
+//	n.POST("/csp-report", mw.CSPReport())
+//
+// It accepts `application/csp-report` and Reporting API
+// `application/reports+json` payloads and responds with "204 - No Content".
+func CSPReport() nio.HandlerFunc {
+	return CSPReportWithConfig(DefaultCSPReportConfig)
+}
+
+// CSPReportWithConfig returns a CSPReport handler with config.
+// See: `CSPReport()`.
+func CSPReportWithConfig(config CSPReportConfig) nio.HandlerFunc {
+	// Defaults
+	if config.Limit == "" {
+		config.Limit = DefaultCSPReportConfig.Limit
+	}
+	if config.DedupWindow == 0 {
+		config.DedupWindow = DefaultCSPReportConfig.DedupWindow
+	}
+	if config.DedupMaxEntries == 0 {
+		config.DedupMaxEntries = DefaultCSPReportConfig.DedupMaxEntries
+	}
+	if config.Sink == nil {
+		config.Sink = DefaultCSPReportConfig.Sink
+	}
+	dedup := &reportDeduper{
+		window:  config.DedupWindow,
+		max:     config.DedupMaxEntries,
+		seen:    map[string]*list.Element{},
+		entries: list.New(),
+	}
+
+	h := func(c nio.Context) error {
+		req := c.Request()
+		ctype, _, _ := mime.ParseMediaType(req.Header.Get(nio.HeaderContentType))
+
+		var violations []CSPViolation
+		var err error
+		switch ctype {
+		case MIMEApplicationCSPReport, nio.MIMEApplicationJSON:
+			violations, err = parseLegacyCSPReport(req)
+		case MIMEApplicationReportsJSON:
+			violations, err = parseReportingAPIReports(req)
+		default:
+			return nio.ErrUnsupportedMediaType
+		}
+		if err != nil {
+			if he, ok := err.(*nio.HTTPError); ok {
+				return he
+			}
+			return nio.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+
+		for _, v := range violations {
+			if v.UserAgent == "" {
+				v.UserAgent = req.UserAgent()
+			}
+			if dedup.seenRecently(v) {
+				continue
+			}
+			config.Sink(c, v)
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+	return BodyLimit(config.Limit)(h)
+}
+
+func parseLegacyCSPReport(req *http.Request) ([]CSPViolation, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(req.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	r := legacyCSPReport{}
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, err
+	}
+	directive := r.Report.EffectiveDirective
+	if directive == "" {
+		directive = r.Report.ViolatedDirective
+	}
+	return []CSPViolation{{
+		Type:               "csp-violation",
+		DocumentURL:        r.Report.DocumentURI,
+		Referrer:           r.Report.Referrer,
+		BlockedURL:         r.Report.BlockedURI,
+		EffectiveDirective: directive,
+		OriginalPolicy:     r.Report.OriginalPolicy,
+		Disposition:        r.Report.Disposition,
+		SourceFile:         r.Report.SourceFile,
+		Sample:             r.Report.ScriptSample,
+		LineNumber:         r.Report.LineNumber,
+		ColumnNumber:       r.Report.ColumnNumber,
+		StatusCode:         r.Report.StatusCode,
+		Body:               raw,
+	}}, nil
+}
+
+func parseReportingAPIReports(req *http.Request) ([]CSPViolation, error) {
+	var reports []reportingAPIReport
+	if err := json.NewDecoder(req.Body).Decode(&reports); err != nil {
+		return nil, err
+	}
+	violations := make([]CSPViolation, 0, len(reports))
+	for _, r := range reports {
+		v := CSPViolation{
+			Type:        r.Type,
+			UserAgent:   r.UserAgent,
+			DocumentURL: r.URL,
+			Body:        r.Body,
+		}
+		if r.Type == "csp-violation" && len(r.Body) > 0 {
+			b := reportingAPIViolation{}
+			if err := json.Unmarshal(r.Body, &b); err != nil {
+				return nil, err
+			}
+			if b.DocumentURL != "" {
+				v.DocumentURL = b.DocumentURL
+			}
+			v.Referrer = b.Referrer
+			v.BlockedURL = b.BlockedURL
+			v.EffectiveDirective = b.EffectiveDirective
+			v.OriginalPolicy = b.OriginalPolicy
+			v.Disposition = b.Disposition
+			v.SourceFile = b.SourceFile
+			v.Sample = b.Sample
+			v.LineNumber = b.LineNumber
+			v.ColumnNumber = b.ColumnNumber
+			v.StatusCode = b.StatusCode
+		}
+		violations = append(violations, v)
+	}
+	return violations, nil
+}
+
+// seenRecently reports whether an identical report was seen within the window
+// and records the report otherwise.
+func (d *reportDeduper) seenRecently(v CSPViolation) bool {
+	if d.window < 0 {
+		return false
+	}
+	key := v.Type + "\x00" + v.DocumentURL + "\x00" + v.BlockedURL + "\x00" + v.EffectiveDirective +
+		"\x00" + v.SourceFile + "\x00" + strconv.Itoa(v.LineNumber) + "\x00" + strconv.Itoa(v.ColumnNumber)
+	if v.Type != "csp-violation" {
+		key += "\x00" + string(v.Body)
+	}
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// Reports are remembered in the order they are seen, so the expired ones
+	// are at the front
+	for e := d.entries.Front(); e != nil && now.Sub(e.Value.(*seenReport).at) > d.window; e = d.entries.Front() {
+		d.forget(e)
+	}
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+	for d.max >= 0 && d.entries.Len() >= d.max {
+		d.forget(d.entries.Front())
+	}
+	d.seen[key] = d.entries.PushBack(&seenReport{key: key, at: now})
+	return false
+}
+
+func (d *reportDeduper) forget(e *list.Element) {
+	delete(d.seen, e.Value.(*seenReport).key)
+	d.entries.Remove(e)
+}
+
+func logCSPViolation(c nio.Context, v CSPViolation) {
+	if v.Type != "csp-violation" {
+		c.Logger().Warningf("[REPORT] %s on %s: %s", v.Type, v.DocumentURL, v.Body)
+		return
+	}
+	c.Logger().Warningf("[CSP VIOLATION] %s blocked %s on %s (%s:%d:%d)",
+		v.EffectiveDirective, v.BlockedURL, v.DocumentURL, v.SourceFile, v.LineNumber, v.ColumnNumber)
+}
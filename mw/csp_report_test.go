@@ -0,0 +1,136 @@
+package mw
+
+import (
+	"container/list"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-nio/nio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSPReport(t *testing.T) {
+	e := nio.New()
+	var violations []CSPViolation
+	h := CSPReportWithConfig(CSPReportConfig{
+		Sink: func(c nio.Context, v CSPViolation) {
+			violations = append(violations, v)
+		},
+	})
+	send := func(ctype, body string) error {
+		req := httptest.NewRequest(http.MethodPost, "/csp-report", strings.NewReader(body))
+		req.Header.Set(nio.HeaderContentType, ctype)
+		req.Header.Set("User-Agent", "test")
+		rec := httptest.NewRecorder()
+		err := h(e.NewContext(req, rec))
+		if err == nil {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+		}
+		return err
+	}
+
+	assert := assert.New(t)
+
+	// report-uri
+	legacy := `{"csp-report": {"document-uri": "https://example.com/", "blocked-uri": "https://evil.com/x.js",
+		"violated-directive": "script-src-elem", "original-policy": "script-src 'self'", "line-number": 10}}`
+	assert.NoError(send(MIMEApplicationCSPReport, legacy))
+	if assert.Len(violations, 1) {
+		v := violations[0]
+		assert.Equal("csp-violation", v.Type)
+		assert.Equal("test", v.UserAgent)
+		assert.Equal("https://example.com/", v.DocumentURL)
+		assert.Equal("https://evil.com/x.js", v.BlockedURL)
+		assert.Equal("script-src-elem", v.EffectiveDirective)
+		assert.Equal(10, v.LineNumber)
+	}
+
+	// Duplicate is dropped
+	assert.NoError(send(MIMEApplicationCSPReport, legacy))
+	assert.Len(violations, 1)
+
+	// Reporting API
+	assert.NoError(send(MIMEApplicationReportsJSON, `[
+		{"type": "csp-violation", "url": "https://example.com/a", "user_agent": "browser",
+		 "body": {"documentURL": "https://example.com/a", "blockedURL": "inline", "effectiveDirective": "style-src", "disposition": "report"}},
+		{"type": "deprecation", "url": "https://example.com/a", "body": {"id": "x"}}
+	]`))
+	if assert.Len(violations, 3) {
+		assert.Equal("browser", violations[1].UserAgent)
+		assert.Equal("inline", violations[1].BlockedURL)
+		assert.Equal("style-src", violations[1].EffectiveDirective)
+		assert.Equal("report", violations[1].Disposition)
+		assert.Equal("deprecation", violations[2].Type)
+		assert.JSONEq(`{"id": "x"}`, string(violations[2].Body))
+	}
+
+	// Errors
+	assert.Equal(nio.ErrUnsupportedMediaType, send(nio.MIMETextPlain, legacy))
+	he, ok := send(MIMEApplicationCSPReport, "{").(*nio.HTTPError)
+	if assert.True(ok) {
+		assert.Equal(http.StatusBadRequest, he.Code)
+	}
+	he, ok = send(MIMEApplicationCSPReport, `{"csp-report": {"document-uri": "`+strings.Repeat("a", 70*1024)+`"}}`).(*nio.HTTPError)
+	if assert.True(ok) {
+		assert.Equal(http.StatusRequestEntityTooLarge, he.Code)
+	}
+	assert.Len(violations, 3)
+}
+
+func TestCSPReportDedupDisabled(t *testing.T) {
+	e := nio.New()
+	count := 0
+	h := CSPReportWithConfig(CSPReportConfig{
+		DedupWindow: -1,
+		Sink:        func(nio.Context, CSPViolation) { count++ },
+	})
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"csp-report": {}}`))
+		req.Header.Set(nio.HeaderContentType, MIMEApplicationCSPReport)
+		assert.NoError(t, h(e.NewContext(req, httptest.NewRecorder())))
+	}
+	assert.Equal(t, 2, count)
+
+	// Default sink logs
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"csp-report": {}}`))
+	req.Header.Set(nio.HeaderContentType, MIMEApplicationCSPReport)
+	assert.NoError(t, CSPReport()(e.NewContext(req, httptest.NewRecorder())))
+}
+
+func TestCSPReportDedupMaxEntries(t *testing.T) {
+	d := &reportDeduper{window: time.Minute, max: 2, seen: map[string]*list.Element{}, entries: list.New()}
+	a, b, c := CSPViolation{BlockedURL: "a"}, CSPViolation{BlockedURL: "b"}, CSPViolation{BlockedURL: "c"}
+	assert.False(t, d.seenRecently(a))
+	assert.False(t, d.seenRecently(b))
+	assert.True(t, d.seenRecently(a))
+
+	// The oldest report is forgotten
+	assert.False(t, d.seenRecently(c))
+	assert.Len(t, d.seen, 2)
+	assert.False(t, d.seenRecently(a))
+	assert.True(t, d.seenRecently(c))
+
+	// Expired reports are forgotten
+	d.entries.Front().Value.(*seenReport).at = time.Now().Add(-2 * time.Minute)
+	assert.False(t, d.seenRecently(c))
+	assert.Len(t, d.seen, 2)
+}
+
+func TestCSPReportDedupUnbounded(t *testing.T) {
+	e := nio.New()
+	count := 0
+	h := CSPReportWithConfig(CSPReportConfig{
+		DedupMaxEntries: -1,
+		Sink:            func(nio.Context, CSPViolation) { count++ },
+	})
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"csp-report": {"blocked-uri": "`+strconv.Itoa(i%2)+`"}}`))
+		req.Header.Set(nio.HeaderContentType, MIMEApplicationCSPReport)
+		assert.NoError(t, h(e.NewContext(req, httptest.NewRecorder())))
+	}
+	assert.Equal(t, 2, count)
+}
@@ -30,7 +30,8 @@ type (
 		// - "query:<name>"
 		TokenLookup string `yaml:"token_lookup"`
 
-		// Context key to store generated CSRF token into context.
+		// Context key to store generated CSRF token into context. The token is
+		// also available through `CSRFTokenKey`.
 		// Optional. Default value "csrf".
 		ContextKey string `yaml:"context_key"`
 
@@ -74,6 +75,9 @@ var (
 		CookieName:   "_csrf",
 		CookieMaxAge: 86400,
 	}
+
+	// CSRFTokenKey is the context key holding the CSRF token.
+	CSRFTokenKey = nio.NewKey[string]("csrf")
 )
 
 // CSRF returns a Cross-Site Request Forgery (CSRF) middleware.
@@ -163,6 +167,7 @@ func CSRFWithConfig(config CSRFConfig) nio.MiddlewareFunc {
 			c.SetCookie(cookie)
 
 			// Store token in the context
+			CSRFTokenKey.Set(c, token)
 			c.Set(config.ContextKey, token)
 
 			// Protect clients from caching the response
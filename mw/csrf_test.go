@@ -27,6 +27,8 @@ func TestCSRF(t *testing.T) {
 	// Generate CSRF token
 	h(c)
 	assert.Contains(t, rec.Header().Get(nio.HeaderSetCookie), "_csrf")
+	assert.Len(t, CSRFTokenKey.Get(c), 16)
+	assert.Equal(t, CSRFTokenKey.Get(c), c.Get("csrf"))
 
 	// Without CSRF cookie
 	req = httptest.NewRequest(http.MethodPost, "/", nil)
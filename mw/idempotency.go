@@ -0,0 +1,352 @@
+package mw
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-nio/nio"
+	nbytes "github.com/go-nio/nio/internal/bytes"
+	"github.com/go-nio/nio/internal/random"
+)
+
+type (
+	// IdempotencyConfig defines the config for Idempotency middleware.
+	IdempotencyConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper nio.Skipper
+
+		// Store keeps the locks and the responses of the keys.
+		// Optional. Default value NewIdempotencyMemoryStore().
+		Store IdempotencyStore
+
+		// Methods are the request methods the middleware applies to.
+		// Optional. Default value []string{"POST", "PATCH"}.
+		Methods []string `yaml:"methods"`
+
+		// TTL is the duration a response is replayed for.
+		// Optional. Default value 24 hours.
+		TTL time.Duration `yaml:"ttl"`
+
+		// LockTimeout is the duration a key stays locked if the request never
+		// completes, e.g. because the server crashed. It must exceed the
+		// duration of the handler, a request still running when its lock
+		// expires doesn't store its response.
+		// Optional. Default value 1 minute.
+		LockTimeout time.Duration `yaml:"lock_timeout"`
+
+		// Limit is the maximum size of a request body, which is read to
+		// fingerprint the request. Larger requests are rejected with
+		// "413 - Request Entity Too Large" response. It can be specified as
+		// `4x` or `4xB`, where x is one of the multiple from K, M, G, T or P.
+		// Optional. Default value "1MB".
+		Limit string `yaml:"limit"`
+		limit int64
+
+		// Required rejects requests without the Idempotency-Key header with
+		// "400 - Bad Request" response.
+		// Optional. Default value false.
+		Required bool `yaml:"required"`
+
+		// KeyFunc returns the store key of the Idempotency-Key header value,
+		// e.g. to scope the keys by user.
+		// Optional. Default value returns the header value.
+		KeyFunc func(c nio.Context, key string) string
+	}
+
+	// IdempotencyStore stores the responses of idempotent requests. It must be
+	// safe for concurrent use.
+	IdempotencyStore interface {
+		// Lock locks the key for the ttl with the owner token, returning false
+		// if it's already locked.
+		Lock(key, token string, ttl time.Duration) (bool, error)
+
+		// Unlock unlocks the key if it's locked with the token.
+		Unlock(key, token string) error
+
+		// Get returns the response of the key, nil if there is none.
+		Get(key string) (*IdempotencyResponse, error)
+
+		// Set stores the response of the key for the ttl if the key is locked
+		// with the token, the response is discarded otherwise.
+		Set(key, token string, res *IdempotencyResponse, ttl time.Duration) error
+	}
+
+	// IdempotencyResponse is a stored response.
+	IdempotencyResponse struct {
+		Fingerprint string // Hash of the request method, URL and body
+		Status      int
+		Header      http.Header
+		Body        []byte
+	}
+
+	// IdempotencyMemoryStore is an in-memory IdempotencyStore, for a single
+	// server.
+	IdempotencyMemoryStore struct {
+		mu        sync.Mutex
+		locks     map[string]idempotencyLock
+		responses map[string]idempotencyEntry
+		swept     time.Time
+	}
+
+	idempotencyLock struct {
+		token   string
+		expires time.Time
+	}
+
+	idempotencyEntry struct {
+		res     *IdempotencyResponse
+		expires time.Time
+	}
+
+	idempotencyResponseWriter struct {
+		http.ResponseWriter
+		body bytes.Buffer
+	}
+)
+
+var (
+	// DefaultIdempotencyConfig is the default Idempotency middleware config.
+	DefaultIdempotencyConfig = IdempotencyConfig{
+		Skipper:     nio.DefaultSkipper,
+		Methods:     []string{http.MethodPost, http.MethodPatch},
+		TTL:         24 * time.Hour,
+		LockTimeout: time.Minute,
+		Limit:       "1MB",
+	}
+)
+
+// Idempotency returns an Idempotency middleware.
+//
+// Idempotency middleware makes retries of unsafe requests with the same
+// Idempotency-Key header safe. The response of the first request is stored and
+// replayed for the retries, with the Idempotent-Replayed header set. For a
+// retry while the first request is in progress it sends "409 - Conflict"
+// response, for a key reused with a different request it sends
+// "422 - Unprocessable Entity" response. Server errors aren't stored, so the
+// request can be retried.
+func Idempotency() nio.MiddlewareFunc {
+	return IdempotencyWithConfig(DefaultIdempotencyConfig)
+}
+
+// IdempotencyWithConfig returns an Idempotency middleware with config.
+// See `Idempotency()`.
+func IdempotencyWithConfig(config IdempotencyConfig) nio.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultIdempotencyConfig.Skipper
+	}
+	if config.Store == nil {
+		config.Store = NewIdempotencyMemoryStore()
+	}
+	if len(config.Methods) == 0 {
+		config.Methods = DefaultIdempotencyConfig.Methods
+	}
+	if config.TTL == 0 {
+		config.TTL = DefaultIdempotencyConfig.TTL
+	}
+	if config.LockTimeout == 0 {
+		config.LockTimeout = DefaultIdempotencyConfig.LockTimeout
+	}
+	if config.Limit == "" {
+		config.Limit = DefaultIdempotencyConfig.Limit
+	}
+	limit, err := nbytes.Parse(config.Limit)
+	if err != nil {
+		panic(fmt.Errorf("nio: invalid idempotency limit=%s", config.Limit))
+	}
+	config.limit = limit
+	methods := map[string]bool{}
+	for _, m := range config.Methods {
+		methods[m] = true
+	}
+
+	return func(next nio.HandlerFunc) nio.HandlerFunc {
+		return func(c nio.Context) (err error) {
+			req := c.Request()
+			if config.Skipper(c) || !methods[req.Method] {
+				return next(c)
+			}
+
+			key := req.Header.Get(nio.HeaderIdempotencyKey)
+			if key == "" {
+				if config.Required {
+					return nio.NewHTTPError(http.StatusBadRequest, "missing idempotency key")
+				}
+				return next(c)
+			}
+			if config.KeyFunc != nil {
+				key = config.KeyFunc(c, key)
+			}
+
+			fingerprint, err := requestFingerprint(req, config.limit)
+			if err != nil {
+				return err
+			}
+
+			stored, err := config.Store.Get(key)
+			if err != nil {
+				return err
+			}
+			token := random.String(32)
+			if stored == nil {
+				var locked bool
+				if locked, err = config.Store.Lock(key, token, config.LockTimeout); err != nil {
+					return err
+				}
+				if !locked {
+					return nio.NewHTTPError(http.StatusConflict, "request with the idempotency key is in progress")
+				}
+				defer func() {
+					if uerr := config.Store.Unlock(key, token); uerr != nil && err == nil {
+						err = uerr
+					}
+				}()
+				// The first request might have completed before the lock
+				if stored, err = config.Store.Get(key); err != nil {
+					return err
+				}
+			}
+			if stored != nil {
+				if stored.Fingerprint != fingerprint {
+					return nio.NewHTTPError(http.StatusUnprocessableEntity, "idempotency key reused with a different request")
+				}
+				return replayResponse(c, stored)
+			}
+
+			res := c.Response()
+			writer := &idempotencyResponseWriter{ResponseWriter: res.Writer}
+			res.Writer = writer
+			defer func() {
+				res.Writer = writer.ResponseWriter
+			}()
+
+			if err = next(c); err != nil {
+				// Send the error response to store it
+				c.Error(err)
+			}
+			if !res.Committed || res.Status >= http.StatusInternalServerError {
+				return
+			}
+			if serr := config.Store.Set(key, token, &IdempotencyResponse{
+				Fingerprint: fingerprint,
+				Status:      res.Status,
+				Header:      res.Header().Clone(),
+				Body:        writer.body.Bytes(),
+			}, config.TTL); serr != nil && err == nil {
+				err = serr
+			}
+			return
+		}
+	}
+}
+
+// requestFingerprint returns the hash of the request method, URL and body of up
+// to limit bytes, restoring the body for the handler.
+func requestFingerprint(req *http.Request, limit int64) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, req.Method+" "+req.URL.Path+"?"+req.URL.RawQuery+"\n")
+	if req.Body != nil {
+		if req.ContentLength > limit {
+			return "", nio.ErrStatusRequestEntityTooLarge
+		}
+		body := &bytes.Buffer{}
+		n, err := io.Copy(io.MultiWriter(h, body), io.LimitReader(req.Body, limit+1))
+		if err != nil {
+			return "", err
+		}
+		if n > limit {
+			return "", nio.ErrStatusRequestEntityTooLarge
+		}
+		req.Body = io.NopCloser(body)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func replayResponse(c nio.Context, stored *IdempotencyResponse) error {
+	res := c.Response()
+	for k, v := range stored.Header {
+		res.Header()[k] = append([]string(nil), v...)
+	}
+	res.Header().Set(nio.HeaderIdempotentReplayed, "true")
+	res.WriteHeader(stored.Status)
+	_, err := res.Write(stored.Body)
+	return err
+}
+
+func (w *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyResponseWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// NewIdempotencyMemoryStore returns an in-memory IdempotencyStore.
+func NewIdempotencyMemoryStore() *IdempotencyMemoryStore {
+	return &IdempotencyMemoryStore{
+		locks:     map[string]idempotencyLock{},
+		responses: map[string]idempotencyEntry{},
+	}
+}
+
+// Lock implements `IdempotencyStore#Lock()`.
+func (s *IdempotencyMemoryStore) Lock(key, token string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.locks[key]; ok && now.Before(l.expires) {
+		return false, nil
+	}
+	s.locks[key] = idempotencyLock{token: token, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// Unlock implements `IdempotencyStore#Unlock()`.
+func (s *IdempotencyMemoryStore) Unlock(key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks[key].token == token {
+		delete(s.locks, key)
+	}
+	return nil
+}
+
+// Get implements `IdempotencyStore#Get()`.
+func (s *IdempotencyMemoryStore) Get(key string) (*IdempotencyResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.responses[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, nil
+	}
+	return e.res, nil
+}
+
+// Set implements `IdempotencyStore#Set()`.
+func (s *IdempotencyMemoryStore) Set(key, token string, res *IdempotencyResponse, ttl time.Duration) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.locks[key]; !ok || l.token != token || !now.Before(l.expires) {
+		return nil // Lock expired, another request might own the key
+	}
+	if now.Sub(s.swept) > time.Minute {
+		// Drop the responses past their TTL at most once a minute, Get
+		// ignores them in between
+		for k, e := range s.responses {
+			if now.After(e.expires) {
+				delete(s.responses, k)
+			}
+		}
+		s.swept = now
+	}
+	s.responses[key] = idempotencyEntry{res: res, expires: now.Add(ttl)}
+	return nil
+}
@@ -0,0 +1,222 @@
+package mw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-nio/nio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	e := nio.New()
+	calls := 0
+	e.POST("/payments", func(c nio.Context) error {
+		calls++
+		c.Response().Header().Set("X-Payment", "1")
+		return c.String(http.StatusCreated, "paid")
+	}, Idempotency())
+
+	request := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(nio.HeaderIdempotencyKey, key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request("a", "10")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "paid", rec.Body.String())
+	assert.Empty(t, rec.Header().Get(nio.HeaderIdempotentReplayed))
+
+	// Retry is replayed
+	rec = request("a", "10")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "paid", rec.Body.String())
+	assert.Equal(t, "1", rec.Header().Get("X-Payment"))
+	assert.Equal(t, "true", rec.Header().Get(nio.HeaderIdempotentReplayed))
+	assert.Equal(t, 1, calls)
+
+	// Key reused with a different body or query
+	rec = request("a", "20")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	req := httptest.NewRequest(http.MethodPost, "/payments?amount=100000", strings.NewReader("10"))
+	req.Header.Set(nio.HeaderIdempotencyKey, "a")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Empty(t, rec.Header().Get(nio.HeaderIdempotentReplayed))
+	assert.Equal(t, 1, calls)
+
+	// Body exceeding the limit
+	rec = request("c", strings.Repeat("1", 2<<20))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, 1, calls)
+
+	// Other key and no key
+	request("b", "10")
+	request("", "10")
+	request("", "10")
+	assert.Equal(t, 4, calls)
+}
+
+func TestIdempotencyConcurrent(t *testing.T) {
+	e := nio.New()
+	started, release := make(chan struct{}), make(chan struct{})
+	e.POST("/", func(c nio.Context) error {
+		close(started)
+		<-release
+		return c.NoContent(http.StatusOK)
+	}, Idempotency())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(nio.HeaderIdempotencyKey, "a")
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-started
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(nio.HeaderIdempotencyKey, "a")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	close(release)
+	wg.Wait()
+}
+
+func TestIdempotencyConfig(t *testing.T) {
+	e := nio.New()
+	calls := 0
+	store := NewIdempotencyMemoryStore()
+	mw := IdempotencyWithConfig(IdempotencyConfig{
+		Store:    store,
+		Required: true,
+		KeyFunc: func(c nio.Context, key string) string {
+			return c.Request().Header.Get("X-User") + ":" + key
+		},
+	})
+	h := mw(func(c nio.Context) error {
+		calls++
+		if calls == 1 {
+			return nio.ErrInternalServerError
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	// Missing key
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	he, ok := h(e.NewContext(req, httptest.NewRecorder())).(*nio.HTTPError)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+	}
+
+	// Server errors aren't stored
+	req.Header.Set(nio.HeaderIdempotencyKey, "a")
+	req.Header.Set("X-User", "jon")
+	rec := httptest.NewRecorder()
+	assert.Equal(t, nio.ErrInternalServerError, h(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NoError(t, h(e.NewContext(req, httptest.NewRecorder())))
+	assert.Equal(t, 2, calls)
+
+	res, err := store.Get("jon:a")
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, http.StatusOK, res.Status)
+	}
+
+	// Safe methods are skipped
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.NoError(t, h(e.NewContext(req, httptest.NewRecorder())))
+	assert.Equal(t, 3, calls)
+}
+
+func TestIdempotencyMemoryStore(t *testing.T) {
+	s := NewIdempotencyMemoryStore()
+	ok, err := s.Lock("a", "t1", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = s.Lock("a", "t2", time.Minute)
+	assert.False(t, ok)
+
+	// Only the owner unlocks
+	assert.NoError(t, s.Unlock("a", "t2"))
+	ok, _ = s.Lock("a", "t2", time.Minute)
+	assert.False(t, ok)
+	assert.NoError(t, s.Unlock("a", "t1"))
+	ok, _ = s.Lock("a", "t2", time.Minute)
+	assert.True(t, ok)
+
+	// Expired lock
+	ok, _ = s.Lock("b", "t1", -time.Second)
+	assert.True(t, ok)
+	ok, _ = s.Lock("b", "t2", time.Minute)
+	assert.True(t, ok)
+
+	// Only the owner stores
+	assert.NoError(t, s.Set("a", "t1", &IdempotencyResponse{Status: http.StatusOK}, time.Minute))
+	res, _ := s.Get("a")
+	assert.Nil(t, res)
+	assert.NoError(t, s.Set("a", "t2", &IdempotencyResponse{Status: http.StatusOK}, time.Minute))
+	res, _ = s.Get("a")
+	assert.NotNil(t, res)
+	assert.NoError(t, s.Set("a", "t2", &IdempotencyResponse{Status: http.StatusOK}, -time.Second))
+	res, _ = s.Get("a")
+	assert.Nil(t, res)
+}
+
+func TestIdempotencyLockTimeout(t *testing.T) {
+	e := nio.New()
+	store := NewIdempotencyMemoryStore()
+	started, release := make(chan struct{}), make(chan struct{})
+	calls := 0
+	e.POST("/", func(c nio.Context) error {
+		calls++
+		if calls == 1 {
+			close(started)
+			<-release
+		}
+		return c.String(http.StatusOK, "done")
+	}, IdempotencyWithConfig(IdempotencyConfig{Store: store, LockTimeout: 10 * time.Millisecond}))
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(nio.HeaderIdempotencyKey, "a")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		request()
+	}()
+	<-started
+	time.Sleep(20 * time.Millisecond)
+
+	// The expired lock is taken over, the first request neither unlocks it nor
+	// stores its response
+	ok, err := store.Lock("a", "other", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	close(release)
+	<-done
+	ok, _ = store.Lock("a", "another", time.Minute)
+	assert.False(t, ok)
+	res, _ := store.Get("a")
+	assert.Nil(t, res)
+	assert.Equal(t, http.StatusConflict, request().Code)
+}
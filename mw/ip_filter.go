@@ -0,0 +1,177 @@
+package mw
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"github.com/go-nio/nio"
+)
+
+type (
+	// IPFilterConfig defines the config for IPFilter middleware.
+	IPFilterConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper nio.Skipper
+
+		// Allow is the list of IPs or CIDR ranges, IPv4 or IPv6, of the clients
+		// allowed to pass. An empty list allows all clients not denied.
+		// Ignored if Rules is set.
+		// Optional. Default value []string{}.
+		Allow []string `yaml:"allow"`
+
+		// Deny is the list of IPs or CIDR ranges of the clients denied, it takes
+		// precedence over Allow.
+		// Ignored if Rules is set.
+		// Optional. Default value []string{}.
+		Deny []string `yaml:"deny"`
+
+		// Rules are the rules to evaluate, which can be updated while the server
+		// is running, see `NewIPRules()`.
+		// Optional. Default value built from Allow and Deny.
+		Rules *IPRules
+
+		// DeniedHandler is called for denied clients with the client IP.
+		// Optional. Default value sends "403 - Forbidden" response.
+		DeniedHandler func(c nio.Context, ip string) error
+	}
+
+	// IPRules are the allow and deny rules of an IPFilter middleware. They are
+	// safe for concurrent use, so they can be reloaded, e.g. from a config
+	// file, with `IPRules#Update()`. The zero value has no rules and allows
+	// all clients.
+	IPRules struct {
+		set atomic.Value // *ipRuleSet
+	}
+
+	ipRuleSet struct {
+		allow []*net.IPNet
+		deny  []*net.IPNet
+	}
+)
+
+var (
+	// DefaultIPFilterConfig is the default IPFilter middleware config.
+	DefaultIPFilterConfig = IPFilterConfig{
+		Skipper: nio.DefaultSkipper,
+		DeniedHandler: func(c nio.Context, ip string) error {
+			return nio.ErrForbidden
+		},
+	}
+)
+
+// IPFilter returns an IPFilter middleware allowing only clients from the IPs or
+// CIDR ranges, e.g. the office networks for an admin group:
+//
+//	admin := n.Group("/admin", mw.IPFilter("203.0.113.0/24", "2001:db8::/32"))
+//
+// The client IP is taken from `Context#RealIP()`, so forwarding headers are
+// only trusted from trusted proxies. For denied clients it sends
+// "403 - Forbidden" response.
+func IPFilter(allow ...string) nio.MiddlewareFunc {
+	c := DefaultIPFilterConfig
+	c.Allow = allow
+	return IPFilterWithConfig(c)
+}
+
+// IPFilterWithConfig returns an IPFilter middleware with config.
+// See `IPFilter()`.
+func IPFilterWithConfig(config IPFilterConfig) nio.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultIPFilterConfig.Skipper
+	}
+	if config.DeniedHandler == nil {
+		config.DeniedHandler = DefaultIPFilterConfig.DeniedHandler
+	}
+	if config.Rules == nil {
+		rules, err := NewIPRules(config.Allow, config.Deny)
+		if err != nil {
+			panic("nio: " + err.Error())
+		}
+		config.Rules = rules
+	}
+
+	return func(next nio.HandlerFunc) nio.HandlerFunc {
+		return func(c nio.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			ip := c.RealIP()
+			if !config.Rules.Allowed(ip) {
+				return config.DeniedHandler(c, ip)
+			}
+			return next(c)
+		}
+	}
+}
+
+// NewIPRules returns the rules for the IPs or CIDR ranges to allow and deny,
+// see `IPFilterConfig`.
+func NewIPRules(allow, deny []string) (*IPRules, error) {
+	r := &IPRules{}
+	if err := r.Update(allow, deny); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update atomically replaces the rules. On error the current rules are kept.
+func (r *IPRules) Update(allow, deny []string) error {
+	a, err := parseIPNets(allow)
+	if err != nil {
+		return err
+	}
+	d, err := parseIPNets(deny)
+	if err != nil {
+		return err
+	}
+	r.set.Store(&ipRuleSet{allow: a, deny: d})
+	return nil
+}
+
+// Allowed reports whether the IP passes the rules. An invalid IP passes only
+// if there are no allow rules.
+func (r *IPRules) Allowed(ip string) bool {
+	set, _ := r.set.Load().(*ipRuleSet)
+	if set == nil {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed != nil && containsIP(set.deny, parsed) {
+		return false
+	}
+	if len(set.allow) == 0 {
+		return true
+	}
+	return parsed != nil && containsIP(set.allow, parsed)
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIPNets parses IPs and CIDR ranges, an IP is a range of a single IP.
+func parseIPNets(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, v := range values {
+		cidr := v
+		if ip := net.ParseIP(v); ip != nil && ip.To4() != nil {
+			cidr += "/32"
+		} else if ip != nil {
+			cidr += "/128"
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ip filter rule %s", v)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
@@ -0,0 +1,97 @@
+package mw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-nio/nio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     IPFilterConfig
+		remoteAddr string
+		xff        string
+		allowed    bool
+	}{
+		{"no rules", IPFilterConfig{}, "192.0.2.1:80", "", true},
+		{"zero rules", IPFilterConfig{Rules: &IPRules{}}, "192.0.2.1:80", "", true},
+		{"allow ipv4", IPFilterConfig{Allow: []string{"192.0.2.0/24"}}, "192.0.2.1:80", "", true},
+		{"allow single ip", IPFilterConfig{Allow: []string{"192.0.2.1"}}, "192.0.2.1:80", "", true},
+		{"not allowed", IPFilterConfig{Allow: []string{"192.0.2.0/24"}}, "198.51.100.1:80", "", false},
+		{"allow ipv6", IPFilterConfig{Allow: []string{"2001:db8::/32"}}, "[2001:db8::1]:80", "", true},
+		{"not allowed ipv6", IPFilterConfig{Allow: []string{"2001:db8::/32"}}, "[2001:db9::1]:80", "", false},
+		{"deny", IPFilterConfig{Deny: []string{"192.0.2.1"}}, "192.0.2.1:80", "", false},
+		{"deny precedes allow", IPFilterConfig{Allow: []string{"192.0.2.0/24"}, Deny: []string{"192.0.2.1"}}, "192.0.2.1:80", "", false},
+		{"trusted proxy", IPFilterConfig{Allow: []string{"192.0.2.0/24"}}, "10.0.0.1:80", "192.0.2.1", true},
+		{"trusted proxy denied", IPFilterConfig{Allow: []string{"192.0.2.0/24"}}, "10.0.0.1:80", "198.51.100.1", false},
+		{"untrusted proxy", IPFilterConfig{Allow: []string{"192.0.2.0/24"}}, "198.51.100.1:80", "192.0.2.1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := nio.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set(nio.HeaderXForwardedFor, tt.xff)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+			h := IPFilterWithConfig(tt.config)(func(c nio.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			err := h(c)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, nio.ErrForbidden, err)
+			}
+		})
+	}
+
+	assert.Panics(t, func() {
+		IPFilter("192.0.2.0/33")
+	})
+}
+
+func TestIPFilterRulesUpdate(t *testing.T) {
+	e := nio.New()
+	rules, err := NewIPRules([]string{"192.0.2.0/24"}, nil)
+	assert.NoError(t, err)
+	var denied string
+	h := IPFilterWithConfig(IPFilterConfig{
+		Rules: rules,
+		DeniedHandler: func(c nio.Context, ip string) error {
+			denied = ip
+			return c.String(http.StatusForbidden, "office only")
+		},
+	})(func(c nio.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "198.51.100.1:80"
+	rec := httptest.NewRecorder()
+	assert.NoError(t, h(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "office only", rec.Body.String())
+	assert.Equal(t, "198.51.100.1", denied)
+
+	assert.NoError(t, rules.Update([]string{"198.51.100.0/24"}, nil))
+	rec = httptest.NewRecorder()
+	assert.NoError(t, h(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Invalid rules keep the current ones
+	assert.Error(t, rules.Update([]string{"invalid"}, nil))
+	assert.True(t, rules.Allowed("198.51.100.1"))
+	assert.False(t, rules.Allowed("invalid"))
+
+	// Zero rules can be updated
+	rules = &IPRules{}
+	assert.True(t, rules.Allowed("198.51.100.1"))
+	assert.NoError(t, rules.Update(nil, []string{"198.51.100.1"}))
+	assert.False(t, rules.Allowed("198.51.100.1"))
+}
@@ -45,6 +45,9 @@ var (
 		KeyLookup:  "header:" + nio.HeaderAuthorization,
 		AuthScheme: "Bearer",
 	}
+
+	// KeyAuthKey is the context key holding the validated key.
+	KeyAuthKey = nio.NewKey[string]("key-auth")
 )
 
 // KeyAuth returns an KeyAuth middleware.
@@ -101,6 +104,7 @@ func KeyAuthWithConfig(config KeyAuthConfig) nio.MiddlewareFunc {
 			if err != nil {
 				return err
 			} else if valid {
+				KeyAuthKey.Set(c, key)
 				return next(c)
 			}
 
@@ -31,6 +31,7 @@ func TestKeyAuth(t *testing.T) {
 	auth := DefaultKeyAuthConfig.AuthScheme + " " + "valid-key"
 	req.Header.Set(nio.HeaderAuthorization, auth)
 	assert.NoError(h(c))
+	assert.Equal("valid-key", KeyAuthKey.Get(c))
 
 	// Invalid key
 	auth = DefaultKeyAuthConfig.AuthScheme + " " + "invalid-key"
@@ -1,7 +1,9 @@
 package mw
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"runtime"
 
 	"github.com/go-nio/nio"
@@ -25,6 +27,33 @@ type (
 		// DisablePrintStack disables printing stack trace.
 		// Optional. Default value as false.
 		DisablePrintStack bool `yaml:"disable_print_stack"`
+
+		// StackFormat is the format of the stack trace, "text" for the output of
+		// `runtime.Stack()` or "json" for a JSON array of `StackFrame` of the
+		// current goroutine, e.g. for structured logging.
+		// Optional. Default value "text".
+		StackFormat string `yaml:"stack_format"`
+
+		// RepanicOnAbort panics again with `http.ErrAbortHandler` instead of
+		// recovering, so the server aborts the response as intended.
+		// Optional. Default value false.
+		RepanicOnAbort bool `yaml:"repanic_on_abort"`
+
+		// PanicHandler is called with the recovered value and the stack trace,
+		// e.g. to report the panic to an external service. The returned error
+		// is handled by the centralized HTTPErrorHandler, nil means the panic
+		// was handled. For panics after the response was committed, which
+		// `c.Response().Committed` tells, the returned error is only logged,
+		// as an error response would corrupt the response already sent.
+		// Optional. Default value returns the panic value as error.
+		PanicHandler func(c nio.Context, value interface{}, stack []byte) error
+	}
+
+	// StackFrame is a frame of the stack trace in the "json" StackFormat.
+	StackFrame struct {
+		Function string `json:"function"`
+		File     string `json:"file"`
+		Line     int    `json:"line"`
 	}
 )
 
@@ -35,6 +64,8 @@ var (
 		StackSize:         4 << 10, // 4 KB
 		DisableStackAll:   false,
 		DisablePrintStack: false,
+		StackFormat:       "text",
+		PanicHandler:      panicError,
 	}
 )
 
@@ -54,6 +85,12 @@ func RecoverWithConfig(config RecoverConfig) nio.MiddlewareFunc {
 	if config.StackSize == 0 {
 		config.StackSize = DefaultRecoverConfig.StackSize
 	}
+	if config.StackFormat == "" {
+		config.StackFormat = DefaultRecoverConfig.StackFormat
+	}
+	if config.PanicHandler == nil {
+		config.PanicHandler = DefaultRecoverConfig.PanicHandler
+	}
 
 	return func(next nio.HandlerFunc) nio.HandlerFunc {
 		return func(c nio.Context) error {
@@ -63,19 +100,70 @@ func RecoverWithConfig(config RecoverConfig) nio.MiddlewareFunc {
 
 			defer func() {
 				if r := recover(); r != nil {
-					err, ok := r.(error)
-					if !ok {
-						err = fmt.Errorf("%v", r)
-					}
-					stack := make([]byte, config.StackSize)
-					length := runtime.Stack(stack, !config.DisableStackAll)
-					if !config.DisablePrintStack {
-						c.Logger().Errorf("[PANIC RECOVER] %v %s\n", err, stack[:length])
+					if config.RepanicOnAbort && r == http.ErrAbortHandler {
+						panic(r)
 					}
-					c.Error(err)
+					recovered(c, config, r)
 				}
 			}()
 			return next(c)
 		}
 	}
 }
+
+// recovered handles the recovered panic value r.
+func recovered(c nio.Context, config RecoverConfig, r interface{}) {
+	stack := panicStack(config)
+	if !config.DisablePrintStack {
+		c.Logger().Errorf("[PANIC RECOVER] %v %s\n", r, stack)
+	}
+	defer func() {
+		// The error handling must not crash the server
+		if r := recover(); r != nil {
+			c.Logger().Errorf("[PANIC RECOVER] panic while handling panic: %v", r)
+		}
+	}()
+	err := config.PanicHandler(c, r, stack)
+	if err == nil {
+		return
+	}
+	if c.Response().Committed {
+		// Headers and maybe part of the body are sent, an error response
+		// would corrupt it
+		c.Logger().Errorf("[PANIC RECOVER] %v after the response was committed", err)
+		return
+	}
+	c.Error(err)
+}
+
+func panicStack(config RecoverConfig) []byte {
+	if config.StackFormat != "json" {
+		stack := make([]byte, config.StackSize)
+		length := runtime.Stack(stack, !config.DisableStackAll)
+		return stack[:length]
+	}
+
+	pc := make([]uintptr, 64)
+	n := runtime.Callers(0, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	var stack []StackFrame
+	for {
+		f, more := frames.Next()
+		stack = append(stack, StackFrame{Function: f.Function, File: f.File, Line: f.Line})
+		if f.Function == "runtime.gopanic" {
+			stack = stack[:0] // Start at the function which panicked
+		}
+		if !more {
+			break
+		}
+	}
+	b, _ := json.Marshal(stack)
+	return b
+}
+
+func panicError(c nio.Context, value interface{}, stack []byte) error {
+	if err, ok := value.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", value)
+}
@@ -1,6 +1,8 @@
 package mw
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -20,3 +22,96 @@ func TestRecover(t *testing.T) {
 	h(c)
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 }
+
+func TestRecoverPanicHandler(t *testing.T) {
+	e := nio.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	var (
+		value interface{}
+		stack []byte
+	)
+	h := RecoverWithConfig(RecoverConfig{
+		DisablePrintStack: true,
+		StackFormat:       "json",
+		PanicHandler: func(c nio.Context, v interface{}, s []byte) error {
+			value, stack = v, s
+			return nio.NewHTTPError(http.StatusServiceUnavailable, "maintenance")
+		},
+	})(func(c nio.Context) error {
+		panic("test")
+	})
+	h(c)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "test", value)
+	var frames []StackFrame
+	if assert.NoError(t, json.Unmarshal(stack, &frames)) && assert.NotEmpty(t, frames) {
+		assert.Contains(t, frames[0].Function, "TestRecoverPanicHandler")
+	}
+
+	// Handled by the panic handler
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	RecoverWithConfig(RecoverConfig{
+		PanicHandler: func(c nio.Context, v interface{}, s []byte) error {
+			assert.Contains(t, string(s), "goroutine")
+			return c.String(http.StatusTeapot, "handled")
+		},
+	})(func(c nio.Context) error {
+		panic(errors.New("test"))
+	})(c)
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Equal(t, "handled", rec.Body.String())
+
+	// Panic in the panic handler
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.NotPanics(t, func() {
+		RecoverWithConfig(RecoverConfig{
+			PanicHandler: func(nio.Context, interface{}, []byte) error {
+				panic("again")
+			},
+		})(func(c nio.Context) error {
+			panic("test")
+		})(c)
+	})
+}
+
+func TestRecoverCommitted(t *testing.T) {
+	e := nio.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	called, committed := false, false
+	h := RecoverWithConfig(RecoverConfig{
+		DisablePrintStack: true,
+		PanicHandler: func(c nio.Context, value interface{}, stack []byte) error {
+			called, committed = true, c.Response().Committed
+			return errors.New("test")
+		},
+	})(func(c nio.Context) error {
+		c.String(http.StatusOK, "partial")
+		panic("test")
+	})
+	assert.NotPanics(t, func() { h(c) })
+	assert.True(t, called)
+	assert.True(t, committed)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "partial", rec.Body.String())
+}
+
+func TestRecoverRepanicOnAbort(t *testing.T) {
+	e := nio.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	abort := func(c nio.Context) error {
+		panic(http.ErrAbortHandler)
+	}
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		RecoverWithConfig(RecoverConfig{RepanicOnAbort: true})(abort)(c)
+	})
+	assert.NotPanics(t, func() {
+		Recover()(abort)(c)
+	})
+}
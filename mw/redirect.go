@@ -0,0 +1,156 @@
+package mw
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-nio/nio"
+)
+
+type (
+	// RedirectConfig defines the config for Redirect middleware.
+	RedirectConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper nio.Skipper
+
+		// Status code to be used when redirecting the request.
+		// Optional. Default value http.StatusMovedPermanently.
+		Code int `yaml:"code"`
+	}
+
+	// redirectLogic returns the redirect URL and whether to redirect for the
+	// scheme, host and request URI of the request.
+	redirectLogic func(scheme, host, uri string) (url string, ok bool)
+)
+
+const www = "www."
+
+var (
+	// DefaultRedirectConfig is the default Redirect middleware config.
+	DefaultRedirectConfig = RedirectConfig{
+		Skipper: nio.DefaultSkipper,
+		Code:    http.StatusMovedPermanently,
+	}
+)
+
+// HTTPSRedirect redirects http requests to https.
+// For example, http://nio.dev will be redirect to https://nio.dev.
+//
+// The scheme is taken from `Context#Scheme()`, so requests forwarded by a
+// trusted proxy terminating TLS are not redirected.
+//
+// Usage `Nio#Pre(HTTPSRedirect())`
+func HTTPSRedirect() nio.MiddlewareFunc {
+	return HTTPSRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// HTTPSRedirectWithConfig returns a HTTPSRedirect middleware with config.
+// See `HTTPSRedirect()`.
+func HTTPSRedirectWithConfig(config RedirectConfig) nio.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (string, bool) {
+		if scheme != "https" {
+			return "https://" + host + uri, true
+		}
+		return "", false
+	})
+}
+
+// HTTPSWWWRedirect redirects http requests to https www.
+// For example, http://nio.dev will be redirect to https://www.nio.dev.
+//
+// Usage `Nio#Pre(HTTPSWWWRedirect())`
+func HTTPSWWWRedirect() nio.MiddlewareFunc {
+	return HTTPSWWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// HTTPSWWWRedirectWithConfig returns a HTTPSWWWRedirect middleware with config.
+// See `HTTPSWWWRedirect()`.
+func HTTPSWWWRedirectWithConfig(config RedirectConfig) nio.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (string, bool) {
+		if scheme != "https" || !strings.HasPrefix(host, www) {
+			return "https://" + www + strings.TrimPrefix(host, www) + uri, true
+		}
+		return "", false
+	})
+}
+
+// HTTPSNonWWWRedirect redirects http requests to https non www.
+// For example, http://www.nio.dev will be redirect to https://nio.dev.
+//
+// Usage `Nio#Pre(HTTPSNonWWWRedirect())`
+func HTTPSNonWWWRedirect() nio.MiddlewareFunc {
+	return HTTPSNonWWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// HTTPSNonWWWRedirectWithConfig returns a HTTPSNonWWWRedirect middleware with config.
+// See `HTTPSNonWWWRedirect()`.
+func HTTPSNonWWWRedirectWithConfig(config RedirectConfig) nio.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (string, bool) {
+		if scheme != "https" || strings.HasPrefix(host, www) {
+			return "https://" + strings.TrimPrefix(host, www) + uri, true
+		}
+		return "", false
+	})
+}
+
+// WWWRedirect redirects non www requests to www.
+// For example, http://nio.dev will be redirect to http://www.nio.dev.
+//
+// Usage `Nio#Pre(WWWRedirect())`
+func WWWRedirect() nio.MiddlewareFunc {
+	return WWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// WWWRedirectWithConfig returns a WWWRedirect middleware with config.
+// See `WWWRedirect()`.
+func WWWRedirectWithConfig(config RedirectConfig) nio.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (string, bool) {
+		if !strings.HasPrefix(host, www) {
+			return scheme + "://" + www + host + uri, true
+		}
+		return "", false
+	})
+}
+
+// NonWWWRedirect redirects www requests to non www.
+// For example, http://www.nio.dev will be redirect to http://nio.dev.
+//
+// Usage `Nio#Pre(NonWWWRedirect())`
+func NonWWWRedirect() nio.MiddlewareFunc {
+	return NonWWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// NonWWWRedirectWithConfig returns a NonWWWRedirect middleware with config.
+// See `NonWWWRedirect()`.
+func NonWWWRedirectWithConfig(config RedirectConfig) nio.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (string, bool) {
+		if strings.HasPrefix(host, www) {
+			return scheme + "://" + host[len(www):] + uri, true
+		}
+		return "", false
+	})
+}
+
+func redirect(config RedirectConfig, logic redirectLogic) nio.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRedirectConfig.Skipper
+	}
+	if config.Code == 0 {
+		config.Code = DefaultRedirectConfig.Code
+	}
+
+	return func(next nio.HandlerFunc) nio.HandlerFunc {
+		return func(c nio.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			if url, ok := logic(c.Scheme(), req.Host, req.URL.RequestURI()); ok {
+				return c.Redirect(config.Code, url)
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package mw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-nio/nio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirect(t *testing.T) {
+	tests := []struct {
+		name       string
+		mw         nio.MiddlewareFunc
+		url        string
+		proto      string
+		remoteAddr string
+		location   string
+	}{
+		{"https", HTTPSRedirect(), "http://nio.dev/a?b=c", "", "", "https://nio.dev/a?b=c"},
+		{"https already", HTTPSRedirect(), "https://nio.dev/", "", "", ""},
+		{"https trusted proxy", HTTPSRedirect(), "http://nio.dev/", "https", "10.0.0.1:80", ""},
+		{"https untrusted proxy", HTTPSRedirect(), "http://nio.dev/", "https", "192.0.2.1:80", "https://nio.dev/"},
+		{"https www", HTTPSWWWRedirect(), "http://nio.dev/", "", "", "https://www.nio.dev/"},
+		{"https www from https", HTTPSWWWRedirect(), "https://nio.dev/", "", "", "https://www.nio.dev/"},
+		{"https www from www", HTTPSWWWRedirect(), "http://www.nio.dev/", "", "", "https://www.nio.dev/"},
+		{"https www already", HTTPSWWWRedirect(), "https://www.nio.dev/", "", "", ""},
+		{"https non www", HTTPSNonWWWRedirect(), "http://www.nio.dev/", "", "", "https://nio.dev/"},
+		{"https non www from http", HTTPSNonWWWRedirect(), "http://nio.dev/", "", "", "https://nio.dev/"},
+		{"https non www already", HTTPSNonWWWRedirect(), "https://nio.dev/", "", "", ""},
+		{"www", WWWRedirect(), "http://nio.dev/a", "", "", "http://www.nio.dev/a"},
+		{"www keeps scheme", WWWRedirect(), "https://nio.dev/", "", "", "https://www.nio.dev/"},
+		{"www already", WWWRedirect(), "http://www.nio.dev/", "", "", ""},
+		{"non www", NonWWWRedirect(), "http://www.nio.dev/a", "", "", "http://nio.dev/a"},
+		{"non www already", NonWWWRedirect(), "http://nio.dev/", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := nio.New()
+			e.Pre(tt.mw)
+			e.GET("/*", func(c nio.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.proto != "" {
+				req.Header.Set(nio.HeaderXForwardedProto, tt.proto)
+			}
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if tt.location == "" {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			} else {
+				assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+				assert.Equal(t, tt.location, rec.Header().Get(nio.HeaderLocation))
+			}
+		})
+	}
+}
+
+func TestRedirectWithConfig(t *testing.T) {
+	e := nio.New()
+	req := httptest.NewRequest(http.MethodGet, "http://nio.dev/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	h := func(c nio.Context) error { return c.NoContent(http.StatusOK) }
+
+	assert.NoError(t, HTTPSRedirectWithConfig(RedirectConfig{Code: http.StatusPermanentRedirect})(h)(c))
+	assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	assert.NoError(t, HTTPSRedirectWithConfig(RedirectConfig{
+		Skipper: func(nio.Context) bool { return true },
+	})(h)(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -23,6 +23,9 @@ var (
 		Skipper:   nio.DefaultSkipper,
 		Generator: generator,
 	}
+
+	// RequestIDKey is the context key holding the request ID.
+	RequestIDKey = nio.NewKey[string]("request-id")
 )
 
 // RequestID returns a X-Request-ID middleware.
@@ -53,6 +56,7 @@ func RequestIDWithConfig(config RequestIDConfig) nio.MiddlewareFunc {
 				rid = config.Generator()
 			}
 			res.Header().Set(nio.HeaderXRequestID, rid)
+			RequestIDKey.Set(c, rid)
 
 			return next(c)
 		}
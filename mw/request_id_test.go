@@ -22,6 +22,7 @@ func TestRequestID(t *testing.T) {
 	h := rid(handler)
 	h(c)
 	assert.Len(t, rec.Header().Get(nio.HeaderXRequestID), 32)
+	assert.Equal(t, rec.Header().Get(nio.HeaderXRequestID), RequestIDKey.Get(c))
 
 	// Custom generator
 	rid = RequestIDWithConfig(RequestIDConfig{
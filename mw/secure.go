@@ -1,7 +1,10 @@
 package mw
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
+	"strings"
 
 	"github.com/go-nio/nio"
 )
@@ -13,7 +16,8 @@ type (
 		Skipper nio.Skipper
 
 		// XSSProtection provides protection against cross-site scripting attack (XSS)
-		// by setting the `X-XSS-Protection` header.
+		// by setting the `X-XSS-Protection` header. The header is deprecated and
+		// ignored by modern browsers, use ContentSecurityPolicy instead.
 		// Optional. Default value "1; mode=block".
 		XSSProtection string `yaml:"xss_protection"`
 
@@ -47,12 +51,56 @@ type (
 		// Optional. Default value false.
 		HSTSExcludeSubdomains bool `yaml:"hsts_exclude_subdomains"`
 
+		// HSTSPreloadEnabled will add the preload tag in the `Strict Transport Security`
+		// header, which enables the domain to be included in the HSTS preload list
+		// maintained by Chrome (and used by Firefox and Safari): https://hstspreload.org/
+		// It has no effect unless HSTSMaxAge is set to a non-zero value.
+		// Optional. Default value false.
+		HSTSPreloadEnabled bool `yaml:"hsts_preload_enabled"`
+
 		// ContentSecurityPolicy sets the `Content-Security-Policy` header providing
 		// security against cross-site scripting (XSS), clickjacking and other code
 		// injection attacks resulting from execution of malicious content in the
-		// trusted web page context.
+		// trusted web page context. Each `{nonce}` placeholder is replaced with a
+		// nonce generated per request and available from `CSPNonceKey`, e.g.
+		// "script-src 'nonce-{nonce}'".
 		// Optional. Default value "".
 		ContentSecurityPolicy string `yaml:"content_security_policy"`
+
+		// CSPReportOnly sets the `Content-Security-Policy-Report-Only` header
+		// instead of `Content-Security-Policy`, so violations are reported but
+		// not enforced.
+		// Optional. Default value false.
+		CSPReportOnly bool `yaml:"csp_report_only"`
+
+		// ReferrerPolicy sets the `Referrer-Policy` header controlling how much
+		// referrer information is sent with requests.
+		// Optional. Default value "strict-origin-when-cross-origin".
+		ReferrerPolicy string `yaml:"referrer_policy"`
+
+		// PermissionsPolicy sets the `Permissions-Policy` header controlling the
+		// browser features the page can use, e.g. "camera=(), geolocation=(self)".
+		// Optional. Default value "".
+		PermissionsPolicy string `yaml:"permissions_policy"`
+
+		// CrossOriginOpenerPolicy sets the `Cross-Origin-Opener-Policy` header,
+		// isolating the browsing context from cross-origin documents.
+		// Optional. Default value "".
+		// Possible values: "unsafe-none", "same-origin-allow-popups", "same-origin".
+		CrossOriginOpenerPolicy string `yaml:"cross_origin_opener_policy"`
+
+		// CrossOriginEmbedderPolicy sets the `Cross-Origin-Embedder-Policy` header,
+		// preventing the page from loading cross-origin resources which don't
+		// grant permission.
+		// Optional. Default value "".
+		// Possible values: "unsafe-none", "require-corp", "credentialless".
+		CrossOriginEmbedderPolicy string `yaml:"cross_origin_embedder_policy"`
+
+		// CrossOriginResourcePolicy sets the `Cross-Origin-Resource-Policy` header,
+		// restricting which origins can load the resources.
+		// Optional. Default value "".
+		// Possible values: "same-site", "same-origin", "cross-origin".
+		CrossOriginResourcePolicy string `yaml:"cross_origin_resource_policy"`
 	}
 )
 
@@ -63,7 +111,12 @@ var (
 		XSSProtection:      "1; mode=block",
 		ContentTypeNosniff: "nosniff",
 		XFrameOptions:      "SAMEORIGIN",
+		ReferrerPolicy:     "strict-origin-when-cross-origin",
 	}
+
+	// CSPNonceKey is the context key holding the CSP nonce of the request, e.g.
+	// for a renderer adding it to inline scripts.
+	CSPNonceKey = nio.NewKey[string]("csp-nonce")
 )
 
 // Secure returns a Secure middleware.
@@ -88,7 +141,6 @@ func SecureWithConfig(config SecureConfig) nio.MiddlewareFunc {
 				return next(c)
 			}
 
-			req := c.Request()
 			res := c.Response()
 
 			if config.XSSProtection != "" {
@@ -100,17 +152,57 @@ func SecureWithConfig(config SecureConfig) nio.MiddlewareFunc {
 			if config.XFrameOptions != "" {
 				res.Header().Set(nio.HeaderXFrameOptions, config.XFrameOptions)
 			}
-			if (c.Request().TLS != nil || (req.Header.Get(nio.HeaderXForwardedProto) == "https")) && config.HSTSMaxAge != 0 {
+			if c.IsTLS() && config.HSTSMaxAge != 0 {
 				subdomains := ""
 				if !config.HSTSExcludeSubdomains {
 					subdomains = "; includeSubdomains"
 				}
+				if config.HSTSPreloadEnabled {
+					subdomains += "; preload"
+				}
 				res.Header().Set(nio.HeaderStrictTransportSecurity, fmt.Sprintf("max-age=%d%s", config.HSTSMaxAge, subdomains))
 			}
 			if config.ContentSecurityPolicy != "" {
-				res.Header().Set(nio.HeaderContentSecurityPolicy, config.ContentSecurityPolicy)
+				policy := config.ContentSecurityPolicy
+				if strings.Contains(policy, "{nonce}") {
+					nonce, err := cspNonce()
+					if err != nil {
+						return err
+					}
+					CSPNonceKey.Set(c, nonce)
+					policy = strings.ReplaceAll(policy, "{nonce}", nonce)
+				}
+				if config.CSPReportOnly {
+					res.Header().Set(nio.HeaderContentSecurityPolicyReportOnly, policy)
+				} else {
+					res.Header().Set(nio.HeaderContentSecurityPolicy, policy)
+				}
+			}
+			if config.ReferrerPolicy != "" {
+				res.Header().Set(nio.HeaderReferrerPolicy, config.ReferrerPolicy)
+			}
+			if config.PermissionsPolicy != "" {
+				res.Header().Set(nio.HeaderPermissionsPolicy, config.PermissionsPolicy)
+			}
+			if config.CrossOriginOpenerPolicy != "" {
+				res.Header().Set(nio.HeaderCrossOriginOpenerPolicy, config.CrossOriginOpenerPolicy)
+			}
+			if config.CrossOriginEmbedderPolicy != "" {
+				res.Header().Set(nio.HeaderCrossOriginEmbedderPolicy, config.CrossOriginEmbedderPolicy)
+			}
+			if config.CrossOriginResourcePolicy != "" {
+				res.Header().Set(nio.HeaderCrossOriginResourcePolicy, config.CrossOriginResourcePolicy)
 			}
 			return next(c)
 		}
 	}
 }
+
+// cspNonce returns a random base64 encoded nonce with 128 bits of entropy.
+func cspNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
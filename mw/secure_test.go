@@ -26,8 +26,9 @@ func TestSecure(t *testing.T) {
 	assert.Equal(t, "", rec.Header().Get(nio.HeaderStrictTransportSecurity))
 	assert.Equal(t, "", rec.Header().Get(nio.HeaderContentSecurityPolicy))
 
-	// Custom
+	// Custom, HTTPS terminated by a trusted proxy
 	req.Header.Set(nio.HeaderXForwardedProto, "https")
+	req.RemoteAddr = "10.0.0.1:1234"
 	rec = httptest.NewRecorder()
 	c = e.NewContext(req, rec)
 	SecureWithConfig(SecureConfig{
@@ -43,3 +44,65 @@ func TestSecure(t *testing.T) {
 	assert.Equal(t, "max-age=3600; includeSubdomains", rec.Header().Get(nio.HeaderStrictTransportSecurity))
 	assert.Equal(t, "default-src 'self'", rec.Header().Get(nio.HeaderContentSecurityPolicy))
 }
+
+func TestSecureModernHeaders(t *testing.T) {
+	e := nio.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(nio.HeaderXForwardedProto, "https")
+	req.RemoteAddr = "10.0.0.1:1234"
+	var nonce string
+	h := func(c nio.Context) error {
+		nonce = CSPNonceKey.Get(c)
+		return c.String(http.StatusOK, "test")
+	}
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	SecureWithConfig(SecureConfig{
+		HSTSMaxAge:                31536000,
+		HSTSPreloadEnabled:        true,
+		ContentSecurityPolicy:     "script-src 'nonce-{nonce}'; style-src 'nonce-{nonce}'",
+		CSPReportOnly:             true,
+		ReferrerPolicy:            "no-referrer",
+		PermissionsPolicy:         "camera=()",
+		CrossOriginOpenerPolicy:   "same-origin",
+		CrossOriginEmbedderPolicy: "require-corp",
+		CrossOriginResourcePolicy: "same-site",
+	})(h)(c)
+	assert.Equal(t, "max-age=31536000; includeSubdomains; preload", rec.Header().Get(nio.HeaderStrictTransportSecurity))
+	assert.Len(t, nonce, 24)
+	assert.Equal(t, "script-src 'nonce-"+nonce+"'; style-src 'nonce-"+nonce+"'", rec.Header().Get(nio.HeaderContentSecurityPolicyReportOnly))
+	assert.Equal(t, "", rec.Header().Get(nio.HeaderContentSecurityPolicy))
+	assert.Equal(t, "no-referrer", rec.Header().Get(nio.HeaderReferrerPolicy))
+	assert.Equal(t, "camera=()", rec.Header().Get(nio.HeaderPermissionsPolicy))
+	assert.Equal(t, "same-origin", rec.Header().Get(nio.HeaderCrossOriginOpenerPolicy))
+	assert.Equal(t, "require-corp", rec.Header().Get(nio.HeaderCrossOriginEmbedderPolicy))
+	assert.Equal(t, "same-site", rec.Header().Get(nio.HeaderCrossOriginResourcePolicy))
+
+	// Nonce is unique per request
+	first := nonce
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	SecureWithConfig(SecureConfig{ContentSecurityPolicy: "script-src 'nonce-{nonce}'"})(h)(c)
+	assert.NotEqual(t, first, nonce)
+	assert.Equal(t, "script-src 'nonce-"+nonce+"'", rec.Header().Get(nio.HeaderContentSecurityPolicy))
+
+	// Default referrer policy, no nonce without placeholder
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	Secure()(h)(c)
+	assert.Equal(t, "strict-origin-when-cross-origin", rec.Header().Get(nio.HeaderReferrerPolicy))
+	assert.Equal(t, "", nonce)
+}
+
+func TestSecureHSTSUntrustedProxy(t *testing.T) {
+	e := nio.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(nio.HeaderXForwardedProto, "https")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	SecureWithConfig(SecureConfig{HSTSMaxAge: 3600})(func(c nio.Context) error {
+		return c.NoContent(http.StatusOK)
+	})(c)
+	assert.Equal(t, "", rec.Header().Get(nio.HeaderStrictTransportSecurity))
+}
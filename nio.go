@@ -38,6 +38,7 @@ package nio
 
 import (
 	"bytes"
+	stdcontext "context"
 	"errors"
 	"fmt"
 	"io"
@@ -46,9 +47,13 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
 
+	"github.com/go-nio/nio/internal/route"
 	"github.com/go-nio/nio/log"
 )
 
@@ -59,12 +64,23 @@ type (
 		middleware       []MiddlewareFunc
 		maxParam         *int
 		router           *router
+		anyRoutes        []anyRoute
+		mounts           []mount
+		vhosts           []*vhost
+		host             string // Host pattern of a virtual host router
+		strictRouting    bool
+		conflicts        []error
+		trustedProxies   trustedProxies
+		ipExtractor      IPExtractor
+		matchers         map[string]ParamMatcher
+		autoOptions      bool
 		notFoundHandler  HandlerFunc
 		pool             sync.Pool
 		logger           log.Logger
 		Debug            bool
 		httpErrorHandler HTTPErrorHandler
 		binder           Binder
+		validator        Validator
 		renderer         Renderer
 	}
 
@@ -73,6 +89,37 @@ type (
 		Method string `json:"method"`
 		Path   string `json:"path"`
 		Name   string `json:"name"`
+
+		// Prefix is the prefix of the group the route was registered with.
+		Prefix string `json:"prefix,omitempty"`
+
+		// Host is the host pattern of the route registered with `Nio#Host()`.
+		Host string `json:"host,omitempty"`
+
+		// Middleware contains the names of the route-level middleware,
+		// including the middleware of the group.
+		Middleware []string `json:"middleware,omitempty"`
+
+		// Description, Tags and Meta hold arbitrary data attached to the route,
+		// e.g. for documentation or authorization middleware.
+		Description string                 `json:"description,omitempty"`
+		Tags        []string               `json:"tags,omitempty"`
+		Meta        map[string]interface{} `json:"meta,omitempty"`
+
+		handler       string // Handler name
+		hidden        bool   // Internal route, e.g. group catch-all
+		overridable   bool   // Registered with Any, may be replaced by a method route
+		router        *router
+		methodHandler *methodHandler
+	}
+
+	// RouteConflictError is a route registration conflicting with an existing
+	// route, either registered for the same method and path or with the same
+	// path but different param names, e.g. `/users/:id` and `/users/:name`.
+	// The router shares the param names of a path between all methods.
+	RouteConflictError struct {
+		Route    *Route
+		Existing *Route
 	}
 
 	// HTTPError represents an error that occurred while handling a request.
@@ -82,12 +129,36 @@ type (
 		Internal error // Stores the error returned by an external dependency
 	}
 
+	// anyRoute is a route registered with `Nio#Any()`, kept to extend it to
+	// methods registered later.
+	anyRoute struct {
+		path       string
+		handler    HandlerFunc
+		hidden     bool
+		middleware []MiddlewareFunc
+	}
+
+	// mount is an http.Handler mounted with `Nio#Mount()`.
+	mount struct {
+		prefix  string
+		handler http.Handler
+	}
+
+	// ParamMatcher reports whether a path param value satisfies a constraint,
+	// e.g. `:id<int>`.
+	ParamMatcher func(value string) bool
+
 	// HandlerFunc defines a function to serve HTTP requests.
 	HandlerFunc func(Context) error
 
 	// HTTPErrorHandler is a centralized HTTP error handler.
 	HTTPErrorHandler func(error, Context)
 
+	// Validator is the interface that wraps the Validate function.
+	Validator interface {
+		Validate(i interface{}) error
+	}
+
 	// Renderer is the interface that wraps the Render function.
 	Renderer interface {
 		Render(io.Writer, string, interface{}, Context) error
@@ -118,12 +189,16 @@ const (
 	MIMETextPlainCharsetUTF8             = MIMETextPlain + "; " + charsetUTF8
 	MIMEMultipartForm                    = "multipart/form-data"
 	MIMEOctetStream                      = "application/octet-stream"
+	MIMETextEventStream                  = "text/event-stream"
 )
 
 const (
 	charsetUTF8 = "charset=UTF-8"
 	// PROPFIND HTTP Header
 	PROPFIND = "PROPFIND"
+	// StatusClientClosedRequest is the non-standard status code used when the
+	// client closes the connection before the response is sent.
+	StatusClientClosedRequest = 499
 )
 
 // Headers
@@ -132,18 +207,25 @@ const (
 	HeaderAcceptEncoding      = "Accept-Encoding"
 	HeaderAllow               = "Allow"
 	HeaderAuthorization       = "Authorization"
+	HeaderCacheControl        = "Cache-Control"
+	HeaderConnection          = "Connection"
 	HeaderContentDisposition  = "Content-Disposition"
 	HeaderContentEncoding     = "Content-Encoding"
 	HeaderContentLength       = "Content-Length"
 	HeaderContentType         = "Content-Type"
 	HeaderCookie              = "Cookie"
 	HeaderSetCookie           = "Set-Cookie"
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
 	HeaderIfModifiedSince     = "If-Modified-Since"
+	HeaderLastEventID         = "Last-Event-ID"
 	HeaderLastModified        = "Last-Modified"
 	HeaderLocation            = "Location"
+	HeaderRetryAfter          = "Retry-After"
 	HeaderUpgrade             = "Upgrade"
 	HeaderVary                = "Vary"
 	HeaderWWWAuthenticate     = "WWW-Authenticate"
+	HeaderForwarded           = "Forwarded"
 	HeaderXForwardedFor       = "X-Forwarded-For"
 	HeaderXForwardedProto     = "X-Forwarded-Proto"
 	HeaderXForwardedProtocol  = "X-Forwarded-Protocol"
@@ -167,12 +249,24 @@ const (
 	HeaderAccessControlMaxAge           = "Access-Control-Max-Age"
 
 	// Security
-	HeaderStrictTransportSecurity = "Strict-Transport-Security"
-	HeaderXContentTypeOptions     = "X-Content-Type-Options"
-	HeaderXXSSProtection          = "X-XSS-Protection"
-	HeaderXFrameOptions           = "X-Frame-Options"
-	HeaderContentSecurityPolicy   = "Content-Security-Policy"
-	HeaderXCSRFToken              = "X-CSRF-Token"
+	HeaderStrictTransportSecurity         = "Strict-Transport-Security"
+	HeaderXContentTypeOptions             = "X-Content-Type-Options"
+	HeaderXXSSProtection                  = "X-XSS-Protection"
+	HeaderXFrameOptions                   = "X-Frame-Options"
+	HeaderContentSecurityPolicy           = "Content-Security-Policy"
+	HeaderContentSecurityPolicyReportOnly = "Content-Security-Policy-Report-Only"
+	HeaderReferrerPolicy                  = "Referrer-Policy"
+	HeaderPermissionsPolicy               = "Permissions-Policy"
+	HeaderCrossOriginOpenerPolicy         = "Cross-Origin-Opener-Policy"
+	HeaderCrossOriginEmbedderPolicy       = "Cross-Origin-Embedder-Policy"
+	HeaderCrossOriginResourcePolicy       = "Cross-Origin-Resource-Policy"
+	HeaderXCSRFToken                      = "X-CSRF-Token"
+
+	// WebSocket
+	HeaderSecWebSocketKey      = "Sec-WebSocket-Key"
+	HeaderSecWebSocketAccept   = "Sec-WebSocket-Accept"
+	HeaderSecWebSocketVersion  = "Sec-WebSocket-Version"
+	HeaderSecWebSocketProtocol = "Sec-WebSocket-Protocol"
 )
 
 var (
@@ -204,8 +298,11 @@ var (
 	ErrInternalServerError         = NewHTTPError(http.StatusInternalServerError)
 	ErrRequestTimeout              = NewHTTPError(http.StatusRequestTimeout)
 	ErrServiceUnavailable          = NewHTTPError(http.StatusServiceUnavailable)
+	ErrClientClosedRequest         = NewHTTPError(StatusClientClosedRequest, "Client Closed Request")
 	ErrValidatorNotRegistered      = errors.New("validator not registered")
 	ErrRendererNotRegistered       = errors.New("renderer not registered")
+	ErrRouteNotFound               = errors.New("route not found")
+	ErrRouteParamMissing           = errors.New("route param missing")
 	ErrInvalidRedirectCode         = errors.New("invalid redirect status code")
 	ErrCookieNotFound              = errors.New("cookie not found")
 )
@@ -217,15 +314,31 @@ var (
 	}
 
 	MethodNotAllowedHandler = func(c Context) error {
+		// The Allow header is required on 405 responses, see RFC 7231 section 6.5.5
+		if allowed := c.AllowedMethods(); len(allowed) > 0 {
+			c.Response().Header().Set(HeaderAllow, strings.Join(allowed, ", "))
+		}
 		return ErrMethodNotAllowed
 	}
+
+	// OptionsHandler answers OPTIONS requests to paths without an OPTIONS
+	// route if enabled with `WithAutoOptions()`.
+	OptionsHandler = func(c Context) error {
+		c.Response().Header().Set(HeaderAllow, strings.Join(c.AllowedMethods(), ", "))
+		return c.NoContent(http.StatusNoContent)
+	}
 )
 
 type options struct {
 	logger           log.Logger
 	binder           Binder
+	validator        Validator
 	renderer         Renderer
 	httpErrorHandler HTTPErrorHandler
+	autoOptions      bool
+	strictRouting    bool
+	trustedProxies   trustedProxies
+	ipExtractor      IPExtractor
 }
 
 // A Option sets options such as credentials, tls, etc.
@@ -245,6 +358,13 @@ func WithBinder(binder Binder) Option {
 	}
 }
 
+// WithValidator allows to register nio validator
+func WithValidator(validator Validator) Option {
+	return func(o *options) {
+		o.validator = validator
+	}
+}
+
 // WithRenderer allows to register nio view renderer
 func WithRenderer(renderer Renderer) Option {
 	return func(o *options) {
@@ -259,6 +379,24 @@ func WithHTTPErrorHandler(handler HTTPErrorHandler) Option {
 	}
 }
 
+// WithAutoOptions enables automatic responses to OPTIONS requests for paths
+// without an OPTIONS route. The response has status 204 and the Allow header set
+// to the methods registered for the path.
+func WithAutoOptions() Option {
+	return func(o *options) {
+		o.autoOptions = true
+	}
+}
+
+// WithStrictRouting makes route registration panic on conflicting routes, see
+// `RouteConflictError`. Without it conflicts are logged and available from
+// `Nio#Conflicts()`.
+func WithStrictRouting() Option {
+	return func(o *options) {
+		o.strictRouting = true
+	}
+}
+
 // New creates an instance of nio.
 func New(opt ...Option) (e *Nio) {
 	opts := options{
@@ -271,10 +409,21 @@ func New(opt ...Option) (e *Nio) {
 	}
 
 	e = &Nio{
-		maxParam: new(int),
-		binder:   opts.binder,
-		logger:   opts.logger,
-		renderer: opts.renderer,
+		maxParam:       new(int),
+		binder:         opts.binder,
+		validator:      opts.validator,
+		logger:         opts.logger,
+		renderer:       opts.renderer,
+		autoOptions:    opts.autoOptions,
+		strictRouting:  opts.strictRouting,
+		trustedProxies: opts.trustedProxies,
+		ipExtractor:    opts.ipExtractor,
+	}
+	if e.trustedProxies == nil {
+		e.trustedProxies = parseTrustedProxies(DefaultTrustedProxies)
+	}
+	if e.ipExtractor == nil {
+		e.ipExtractor = extractIPFromXFFHeader(e.trustedProxies)
 	}
 
 	// http error handler must be set after nio instance
@@ -368,11 +517,21 @@ func (e *Nio) TRACE(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
 }
 
 // Any registers a new route for all HTTP methods and path with matching handler
-// in the router with optional route-level middleware.
+// in the router with optional route-level middleware. Besides the standard
+// methods it covers all custom methods registered with `Nio#Add()`, including
+// the ones registered later.
 func (e *Nio) Any(path string, handler HandlerFunc, middleware ...MiddlewareFunc) []*Route {
-	routes := make([]*Route, len(methods))
-	for i, m := range methods {
-		routes[i] = e.Add(m, path, handler, middleware...)
+	return e.any(path, handler, false, middleware)
+}
+
+func (e *Nio) any(path string, handler HandlerFunc, hidden bool, middleware []MiddlewareFunc) []*Route {
+	e.anyRoutes = append(e.anyRoutes, anyRoute{path, handler, hidden, middleware})
+	routes := make([]*Route, 0, len(methods)+len(e.router.methods))
+	for _, m := range methods {
+		routes = append(routes, e.add(&Route{Method: m, Path: path, hidden: hidden, overridable: true}, handler, middleware))
+	}
+	for _, m := range e.router.methods {
+		routes = append(routes, e.add(&Route{Method: m, Path: path, hidden: hidden, overridable: true}, handler, middleware))
 	}
 	return routes
 }
@@ -421,26 +580,145 @@ func (e *Nio) File(path, file string, m ...MiddlewareFunc) *Route {
 }
 
 // Add registers a new route for an HTTP method and path with matching handler
-// in the router with optional route-level middleware.
+// in the router with optional route-level middleware. Method can be any valid
+// token, e.g. WebDAV methods such as MKCOL or custom verbs such as PURGE.
 func (e *Nio) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route {
+	return e.add(&Route{Method: method, Path: path}, handler, middleware)
+}
+
+// add registers the route with method and path of r, which also carries the
+// internal flags of the route.
+func (e *Nio) add(r *Route, handler HandlerFunc, middleware []MiddlewareFunc) *Route {
+	method, path := r.Method, r.Path
+	if validMethod(method) && e.router.addMethod(method) {
+		// Extend routes registered with Any to the new method first, so they
+		// don't override the route being registered.
+		for _, a := range e.anyRoutes {
+			e.add(&Route{Method: method, Path: a.path, hidden: a.hidden, overridable: true}, a.handler, a.middleware)
+		}
+	}
+	if err := e.router.conflict(r); err != nil {
+		if e.strictRouting {
+			panic("nio: " + err.Error())
+		}
+		e.conflicts = append(e.conflicts, err)
+		e.logger.Warningf("nio: %v", err)
+	}
 	name := handlerName(handler)
-	e.router.add(method, path, func(c Context) error {
-		h := handler
+	handle := func(c Context) error {
+		// Stop before the handler if the client is gone
+		if err := contextError(c); err != nil {
+			return err
+		}
+		return handler(c)
+	}
+	mh := e.router.add(method, path, func(c Context) error {
+		h := handle
 		// Chain middleware
 		for i := len(middleware) - 1; i >= 0; i-- {
 			h = middleware[i](h)
 		}
 		return h(c)
 	})
-	r := &Route{
-		Method: method,
-		Path:   path,
-		Name:   name,
+	r.Name = name
+	r.Host = e.host
+	r.handler = name
+	r.router = e.router
+	r.methodHandler = mh
+	for _, m := range middleware {
+		r.Middleware = append(r.Middleware, funcName(m))
 	}
-	e.router.routes[method+path] = r
+	e.router.addRoute(r)
+	mh.setRoute(method, r)
 	return r
 }
 
+// RegisterMatcher registers a named param matcher which can be used as a param
+// constraint in route paths, e.g. `/posts/:slug<slug>`. Matchers must be
+// registered before the routes using them. Built-in matchers are `int`, `uint`,
+// `alpha`, `alnum` and `uuid`, any other constraint is used as a regular
+// expression matching the whole param value.
+func (e *Nio) RegisterMatcher(name string, m ParamMatcher) {
+	if e.matchers == nil {
+		e.matchers = map[string]ParamMatcher{}
+	}
+	e.matchers[name] = m
+}
+
+func (e *Nio) paramMatcher(constraint string) ParamMatcher {
+	if m, ok := e.matchers[constraint]; ok {
+		return m
+	}
+	if m, ok := paramMatchers[constraint]; ok {
+		return m
+	}
+	re, err := regexp.Compile("^(?:" + constraint + ")$")
+	if err != nil {
+		panic("nio: invalid param constraint " + constraint)
+	}
+	return re.MatchString
+}
+
+// SetName sets an explicit name for the route, used by `Nio#Reverse()`
+// instead of the handler name. It panics if another route already has the
+// name.
+func (r *Route) SetName(name string) *Route {
+	if other, ok := r.router.names[name]; ok && other != r {
+		panic("nio: duplicate route name " + name)
+	}
+	if r.router.names[r.Name] == r {
+		delete(r.router.names, r.Name)
+	}
+	r.Name = name
+	r.router.names[name] = r
+	return r
+}
+
+// SetDescription sets the description of the route.
+func (r *Route) SetDescription(description string) *Route {
+	r.Description = description
+	return r
+}
+
+// AddTags adds tags to the route.
+func (r *Route) AddTags(tags ...string) *Route {
+	r.Tags = append(r.Tags, tags...)
+	return r
+}
+
+// SetMeta sets a metadata value of the route, e.g. the scopes required to
+// access it.
+func (r *Route) SetMeta(key string, value interface{}) *Route {
+	if r.Meta == nil {
+		r.Meta = map[string]interface{}{}
+	}
+	r.Meta[key] = value
+	return r
+}
+
+// GetMeta returns a metadata value of the route.
+func (r *Route) GetMeta(key string) interface{} {
+	return r.Meta[key]
+}
+
+// NoAutoHead disables answering HEAD requests with the handler of the GET
+// route. It has no effect on routes for other methods.
+func (r *Route) NoAutoHead() *Route {
+	if r.Method == http.MethodGet && r.methodHandler != nil {
+		r.methodHandler.noAutoHead = true
+		r.methodHandler.updateAllowed()
+	}
+	return r
+}
+
+// WS registers a new WebSocket route for a path with matching handler in the
+// router with optional route-level middleware. The connection is upgraded using
+// `DefaultWebSocketConfig`, use `WebSocketWithConfig()` with `GET` for custom
+// configuration. HEAD requests aren't answered by the route.
+func (e *Nio) WS(path string, h WebSocketHandler, m ...MiddlewareFunc) *Route {
+	return e.GET(path, WebSocket(h), m...).NoAutoHead()
+}
+
 // Group creates a new router group with prefix and optional group-level middleware.
 func (e *Nio) Group(prefix string, m ...MiddlewareFunc) (g *Group) {
 	g = &Group{prefix: prefix, nio: e}
@@ -451,7 +729,13 @@ func (e *Nio) Group(prefix string, m ...MiddlewareFunc) (g *Group) {
 // URI generates a URI from handler.
 func (e *Nio) URI(handler HandlerFunc, params ...interface{}) string {
 	name := handlerName(handler)
-	return e.Reverse(name, params...)
+	for _, r := range e.router.list {
+		if r.handler == name {
+			uri, _ := reverse(r, params)
+			return uri
+		}
+	}
+	return ""
 }
 
 // URL is an alias for `URI` function.
@@ -459,39 +743,183 @@ func (e *Nio) URL(h HandlerFunc, params ...interface{}) string {
 	return e.URI(h, params...)
 }
 
-// Reverse generates an URL from route name and provided parameters.
+// Reverse generates an URL from route name and provided parameters. Params
+// replace path params and the `*` wildcard in order and are URL-escaped. If the
+// last param is `url.Values` it is encoded as query string. If several routes
+// share a name without one set explicitly, the first registered is used.
 func (e *Nio) Reverse(name string, params ...interface{}) string {
+	uri, _ := e.ReverseStrict(name, params...)
+	return uri
+}
+
+// ReverseStrict is like `Reverse()`, but returns an error if there is no route
+// with the name or if a path param is missing.
+func (e *Nio) ReverseStrict(name string, params ...interface{}) (string, error) {
+	r := e.router.named(name)
+	if r == nil {
+		return "", fmt.Errorf("%w: %s", ErrRouteNotFound, name)
+	}
+	return reverse(r, params)
+}
+
+func reverse(r *Route, params []interface{}) (string, error) {
+	var query url.Values
+	if l := len(params); l > 0 {
+		if q, ok := params[l-1].(url.Values); ok {
+			query = q
+			params = params[:l-1]
+		}
+	}
+
+	var err error
 	uri := new(bytes.Buffer)
 	ln := len(params)
 	n := 0
-	for _, r := range e.router.routes {
-		if r.Name == name {
-			for i, l := 0, len(r.Path); i < l; i++ {
-				if r.Path[i] == ':' && n < ln {
-					for ; i < l && r.Path[i] != '/'; i++ {
-					}
-					uri.WriteString(fmt.Sprintf("%v", params[n]))
-					n++
+	for i, l := 0, len(r.Path); i < l; i++ {
+		switch r.Path[i] {
+		case ':':
+			j := i
+			for ; i < l && r.Path[i] != '/' && r.Path[i] != '<'; i++ {
+			}
+			name := r.Path[j:i]
+			if i < l && r.Path[i] == '<' {
+				// Skip the constraint, which may contain slashes
+				if k := route.ConstraintEnd(r.Path, i); k != -1 {
+					i = k + 1
+				}
+			}
+			if n < ln {
+				uri.WriteString(url.PathEscape(fmt.Sprintf("%v", params[n])))
+				n++
+			} else {
+				// Keep the param in the path
+				uri.WriteString(name)
+				if err == nil {
+					err = fmt.Errorf("%w: %s in %s", ErrRouteParamMissing, name, r.Path)
 				}
-				if i < l {
-					uri.WriteByte(r.Path[i])
+			}
+			if i < l {
+				uri.WriteByte(r.Path[i])
+			}
+		case '*':
+			if n < ln {
+				// Escape segments, keeping the slashes
+				for k, s := range strings.Split(fmt.Sprintf("%v", params[n]), "/") {
+					if k > 0 {
+						uri.WriteByte('/')
+					}
+					uri.WriteString(url.PathEscape(s))
 				}
+				n++
 			}
-			break
+		default:
+			uri.WriteByte(r.Path[i])
 		}
 	}
-	return uri.String()
+	if len(query) > 0 {
+		uri.WriteByte('?')
+		uri.WriteString(query.Encode())
+	}
+	return uri.String(), err
 }
 
-// Routes returns the registered routes.
+// Mount serves all requests to paths under prefix with the handler, with the
+// prefix stripped from the request path. The handler can be another nio
+// instance, which keeps its own middleware, binder and error handler.
+func (e *Nio) Mount(prefix string, h http.Handler, m ...MiddlewareFunc) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	handler := func(c Context) error {
+		h.ServeHTTP(c.Response(), stripPrefix(c.Request(), prefix))
+		return nil
+	}
+	if prefix == "" {
+		e.any("/", handler, true, m)
+	} else {
+		e.any(prefix, handler, true, m)
+	}
+	e.any(prefix+"/*", handler, true, m)
+	e.mounts = append(e.mounts, mount{prefix, h})
+}
+
+// stripPrefix returns a shallow copy of the request with the prefix removed
+// from the path.
+func stripPrefix(r *http.Request, prefix string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path = "/" + strings.TrimLeft(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if r.URL.RawPath != "" {
+		r2.URL.RawPath = "/" + strings.TrimLeft(strings.TrimPrefix(r.URL.RawPath, prefix), "/")
+	}
+	return r2
+}
+
+// Routes returns the registered routes sorted by path, host and method. Internal
+// routes, e.g. the catch-all routes of groups, are omitted. Routes of mounted
+// nio instances are included with the mount prefix, other mounted handlers are
+// listed as a single route with method "*".
 func (e *Nio) Routes() []*Route {
-	routes := make([]*Route, 0, len(e.router.routes))
-	for _, v := range e.router.routes {
-		routes = append(routes, v)
+	routes := make([]*Route, 0, len(e.router.list))
+	for _, r := range e.router.list {
+		if !r.hidden {
+			routes = append(routes, r)
+		}
+	}
+	for _, m := range e.mounts {
+		sub, ok := m.handler.(*Nio)
+		if !ok {
+			name := funcName(m.handler)
+			routes = append(routes, &Route{Method: "*", Path: m.prefix + "/*", Name: name, Prefix: m.prefix, handler: name})
+			continue
+		}
+		for _, r := range sub.Routes() {
+			mounted := *r
+			mounted.Path = m.prefix + r.Path
+			mounted.Prefix = m.prefix + r.Prefix
+			routes = append(routes, &mounted)
+		}
+	}
+	for _, v := range e.vhosts {
+		routes = append(routes, v.nio.Routes()...)
 	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		return routes[i].Method < routes[j].Method
+	})
 	return routes
 }
 
+// Conflicts returns the route conflicts detected during registration, see
+// `RouteConflictError`.
+func (e *Nio) Conflicts() []error {
+	conflicts := append([]error(nil), e.conflicts...)
+	for _, v := range e.vhosts {
+		conflicts = append(conflicts, v.nio.Conflicts()...)
+	}
+	return conflicts
+}
+
+// DumpTree writes the radix tree of the router to w for troubleshooting
+// routing. Each line is a node with its prefix, param constraint, methods and
+// the path the node was registered with.
+func (e *Nio) DumpTree(w io.Writer) {
+	e.router.tree.dump(w, 0)
+	for _, v := range e.vhosts {
+		host := v.nio.host
+		if host == "" {
+			host = "(predicate)"
+		}
+		fmt.Fprintf(w, "\nhost %s\n", host)
+		v.nio.router.tree.dump(w, 0)
+	}
+}
+
 // ServeHTTP implements `http.Handler` interface, which serves HTTP requests.
 func (e *Nio) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Acquire context
@@ -501,14 +929,14 @@ func (e *Nio) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var h HandlerFunc
 
 	if e.premiddleware == nil {
-		e.router.find(r.Method, getPath(r), c)
+		e.find(r, c)
 		h = c.Handler()
 		for i := len(e.middleware) - 1; i >= 0; i-- {
 			h = e.middleware[i](h)
 		}
 	} else {
 		h = func(c Context) error {
-			e.router.find(r.Method, getPath(r), c)
+			e.find(r, c)
 			h := c.Handler()
 			for i := len(e.middleware) - 1; i >= 0; i-- {
 				h = e.middleware[i](h)
@@ -520,10 +948,17 @@ func (e *Nio) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Execute chain
-	if err := h(c); err != nil {
+	// Execute chain unless the client has already disconnected
+	err := contextError(c)
+	if err == nil {
+		err = h(c)
+	}
+	if err != nil {
 		e.httpErrorHandler(err, c)
 	}
+	if c.head != nil {
+		c.head.finish()
+	}
 
 	// Release context
 	e.pool.Put(c)
@@ -570,6 +1005,15 @@ func (e *Nio) defaultHTTPErrorHandler(err error, c Context) {
 	}
 }
 
+// Error makes it compatible with `error` interface.
+func (e *RouteConflictError) Error() string {
+	if e.Route.Method == e.Existing.Method && e.Route.Path == e.Existing.Path {
+		return "duplicate route " + e.Route.Method + " " + e.Route.Path
+	}
+	return "route " + e.Route.Method + " " + e.Route.Path + " conflicts with " +
+		e.Existing.Method + " " + e.Existing.Path + ", param names differ"
+}
+
 // NewHTTPError creates a new HTTPError instance.
 func NewHTTPError(code int, message ...interface{}) *HTTPError {
 	he := &HTTPError{Code: code, Message: http.StatusText(code)}
@@ -598,6 +1042,18 @@ func WrapHandler(h http.Handler) HandlerFunc {
 	}
 }
 
+// contextError maps the error of a cancelled request context to an HTTPError.
+func contextError(c Context) error {
+	switch c.Err() {
+	case nil:
+		return nil
+	case stdcontext.DeadlineExceeded:
+		return ErrServiceUnavailable
+	default:
+		return ErrClientClosedRequest
+	}
+}
+
 func getPath(r *http.Request) string {
 	path := r.URL.RawPath
 	if path == "" {
@@ -607,9 +1063,13 @@ func getPath(r *http.Request) string {
 }
 
 func handlerName(h HandlerFunc) string {
-	t := reflect.ValueOf(h).Type()
+	return funcName(h)
+}
+
+func funcName(f interface{}) string {
+	t := reflect.ValueOf(f).Type()
 	if t.Kind() == reflect.Func {
-		return runtime.FuncForPC(reflect.ValueOf(h).Pointer()).Name()
+		return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
 	}
 	return t.String()
 }
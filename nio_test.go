@@ -2,11 +2,13 @@ package nio
 
 import (
 	"bytes"
+	stdcontext "context"
 	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
@@ -260,6 +262,45 @@ func TestNioAny(t *testing.T) { // JFC
 	})
 }
 
+func TestNioAnyCustomMethod(t *testing.T) {
+	e := New()
+	e.Add("MKCOL", "/dav", func(c Context) error {
+		return c.String(http.StatusCreated, "MKCOL")
+	})
+	routes := e.Any("/", func(c Context) error {
+		return c.String(http.StatusOK, "Any")
+	})
+	assert.Len(t, routes, len(methods)+1)
+
+	// Methods registered after Any are covered as well
+	e.Group("/g", func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			c.Response().Header().Set("X-Group", "g")
+			return next(c)
+		}
+	})
+	e.Add("PURGE", "/cache", func(c Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	for _, m := range []string{"MKCOL", "PURGE", http.MethodGet} {
+		code, body := request(m, "/", e)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "Any", body)
+	}
+	code, body := request("MKCOL", "/dav", e)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "MKCOL", body)
+	code, _ = request("PURGE", "/cache", e)
+	assert.Equal(t, http.StatusNoContent, code)
+
+	req := httptest.NewRequest("PURGE", "/g/unknown", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "g", rec.Header().Get("X-Group"))
+}
+
 func TestNioMatch(t *testing.T) { // JFC
 	e := New()
 	e.Match([]string{http.MethodGet, http.MethodPost}, "/", func(c Context) error {
@@ -287,13 +328,56 @@ func TestNioURL(t *testing.T) {
 	assert.Equal("/group/users/1/files/1", e.URL(getFile, "1", "1"))
 }
 
+func TestNioReverse(t *testing.T) {
+	e := New()
+	h := func(Context) error { return nil }
+	e.GET("/users/:id<int>", h).SetName("user.show")
+	e.GET("/users/:id/files/:name", h).SetName("user.file")
+	e.GET("/static/*", h).SetName("static")
+	e.GET("/first", h)
+	e.GET("/second", h)
+
+	assert := assert.New(t)
+
+	assert.Equal("/users/1", e.Reverse("user.show", 1))
+	assert.Equal("/users/a%2Fb/files/my%20file.txt", e.Reverse("user.file", "a/b", "my file.txt"))
+	assert.Equal("/users/1?sort=asc", e.Reverse("user.show", 1, url.Values{"sort": {"asc"}}))
+	assert.Equal("/static/css/main%20v2.css", e.Reverse("static", "css/main v2.css"))
+	assert.Equal("/static/", e.Reverse("static"))
+	assert.Equal("/first", e.Reverse(handlerName(h)))
+
+	uri, err := e.ReverseStrict("user.file", 1)
+	assert.Equal("/users/1/files/:name", uri)
+	assert.True(errors.Is(err, ErrRouteParamMissing))
+	_, err = e.ReverseStrict("unknown")
+	assert.True(errors.Is(err, ErrRouteNotFound))
+	uri, err = e.ReverseStrict("user.show", 2)
+	assert.NoError(err)
+	assert.Equal("/users/2", uri)
+
+	// Constraints with slashes
+	e.GET("/r/:id<[a-z/]+>/edit", h).SetName("slash")
+	assert.Equal("/r/a%2Fb/edit", e.Reverse("slash", "a/b"))
+	uri, err = e.ReverseStrict("slash")
+	assert.Equal("/r/:id/edit", uri)
+	assert.True(errors.Is(err, ErrRouteParamMissing))
+
+	// Duplicate names
+	assert.Panics(func() {
+		e.GET("/other", h).SetName("user.show")
+	})
+	r := e.GET("/users/:id<int>", h)
+	r.SetName("user.show")
+	assert.Equal("/users/3", e.Reverse("user.show", 3))
+}
+
 func TestNioRoutes(t *testing.T) {
 	e := New()
 	routes := []*Route{
-		{http.MethodGet, "/users/:user/events", ""},
-		{http.MethodGet, "/users/:user/events/public", ""},
-		{http.MethodPost, "/repos/:owner/:repo/git/refs", ""},
-		{http.MethodPost, "/repos/:owner/:repo/git/tags", ""},
+		{Method: http.MethodGet, Path: "/users/:user/events"},
+		{Method: http.MethodGet, Path: "/users/:user/events/public"},
+		{Method: http.MethodPost, Path: "/repos/:owner/:repo/git/refs"},
+		{Method: http.MethodPost, Path: "/repos/:owner/:repo/git/tags"},
 	}
 	for _, r := range routes {
 		e.Add(r.Method, r.Path, func(c Context) error {
@@ -317,6 +401,148 @@ func TestNioRoutes(t *testing.T) {
 	}
 }
 
+func TestNioRoutesMeta(t *testing.T) {
+	e := New()
+	auth := func(next HandlerFunc) HandlerFunc { return next }
+	var route *Route
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			route = c.Route()
+			return next(c)
+		}
+	})
+	h := func(c Context) error { return c.NoContent(http.StatusOK) }
+	e.POST("/b", h)
+	e.GET("/b", h)
+	g := e.Group("/api", auth)
+	g.GET("/users", h, auth).
+		SetDescription("List users").
+		AddTags("users").
+		SetMeta("scopes", []string{"users:read"})
+
+	assert := assert.New(t)
+
+	routes := e.Routes()
+	if assert.Len(routes, 3) {
+		assert.Equal("/api/users", routes[0].Path)
+		assert.Equal("GET /b", routes[1].Method+" "+routes[1].Path)
+		assert.Equal("POST /b", routes[2].Method+" "+routes[2].Path)
+	}
+	r := routes[0]
+	assert.Equal("/api", r.Prefix)
+	assert.Len(r.Middleware, 2)
+	assert.Contains(r.Middleware[0], "TestNioRoutesMeta.func")
+	assert.Equal("List users", r.Description)
+	assert.Equal([]string{"users"}, r.Tags)
+	assert.Equal([]string{"users:read"}, r.GetMeta("scopes"))
+
+	request(http.MethodGet, "/api/users", e)
+	assert.Equal(r, route)
+	request(http.MethodHead, "/b", e)
+	assert.Equal(routes[1], route)
+	request(http.MethodGet, "/unknown", e)
+	assert.Nil(route)
+	request(http.MethodPut, "/b", e)
+	assert.Nil(route)
+}
+
+func TestNioConflicts(t *testing.T) {
+	e := New()
+	h := func(Context) error { return nil }
+	e.Any("/any", h)
+	e.GET("/any", h) // Replaces the Any route
+	e.GET("/users/:id", h)
+	e.POST("/users/:id", h)
+	e.GET("/users/:id", h)
+	e.PUT("/users/:name", h)
+	e.Group("/users").Use(func(next HandlerFunc) HandlerFunc { return next })
+	e.Host("api.example.com").GET("/users/:id", h)
+
+	conflicts := e.Conflicts()
+	if assert.Len(t, conflicts, 2) {
+		assert.Equal(t, "duplicate route GET /users/:id", conflicts[0].Error())
+		assert.Equal(t, "route PUT /users/:name conflicts with GET /users/:id, param names differ", conflicts[1].Error())
+		var err *RouteConflictError
+		if assert.True(t, errors.As(conflicts[1], &err)) {
+			assert.Equal(t, "/users/:name", err.Route.Path)
+			assert.Equal(t, "/users/:id", err.Existing.Path)
+		}
+	}
+
+	e = New(WithStrictRouting())
+	e.GET("/users/:id<int>", h)
+	e.GET("/users/:name", h) // Different constraint
+	assert.PanicsWithValue(t, "nio: route POST /users/:uid<int> conflicts with GET /users/:id<int>, param names differ", func() {
+		e.POST("/users/:uid<int>", h)
+	})
+	api := e.Host("api.example.com")
+	api.GET("/", h)
+	assert.PanicsWithValue(t, "nio: duplicate route GET /", func() {
+		api.GET("/", h)
+	})
+}
+
+func TestNioMount(t *testing.T) {
+	admin := New(WithHTTPErrorHandler(func(err error, c Context) {
+		c.String(http.StatusTeapot, "admin: "+err.Error())
+	}))
+	admin.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			c.Response().Header().Set("X-Admin", "1")
+			return next(c)
+		}
+	})
+	admin.GET("/", func(c Context) error { return c.String(http.StatusOK, "index") })
+	admin.GET("/users/:id", func(c Context) error {
+		return c.String(http.StatusOK, c.Request().URL.Path+" "+c.Param("id"))
+	})
+
+	e := New()
+	e.GET("/users/:id", func(c Context) error { return c.String(http.StatusOK, "parent") })
+	e.Mount("/admin/", admin)
+	e.Mount("/debug", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("debug " + r.URL.Path))
+	}))
+
+	assert := assert.New(t)
+
+	// Prefix is stripped
+	code, body := request(http.MethodGet, "/admin/users/1", e)
+	assert.Equal(http.StatusOK, code)
+	assert.Equal("/users/1 1", body)
+	code, body = request(http.MethodGet, "/admin", e)
+	assert.Equal(http.StatusOK, code)
+	assert.Equal("index", body)
+	_, body = request(http.MethodGet, "/admin/", e)
+	assert.Equal("index", body)
+	_, body = request(http.MethodGet, "/users/1", e)
+	assert.Equal("parent", body)
+	_, body = request(http.MethodGet, "/debug/pprof", e)
+	assert.Equal("debug /pprof", body)
+	code, _ = request(http.MethodGet, "/administrator", e)
+	assert.Equal(http.StatusNotFound, code)
+
+	// Mounted instance keeps its middleware and error handler
+	req := httptest.NewRequest(http.MethodPost, "/admin/users/1", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(http.StatusTeapot, rec.Code)
+	assert.Equal("admin: code=405, message=Method Not Allowed", rec.Body.String())
+	assert.Equal("1", rec.Header().Get("X-Admin"))
+
+	// Introspection
+	var paths []string
+	for _, r := range e.Routes() {
+		paths = append(paths, r.Method+" "+r.Path)
+	}
+	assert.Equal([]string{
+		"GET /admin/",
+		"GET /admin/users/:id",
+		"* /debug/*",
+		"GET /users/:id",
+	}, paths)
+}
+
 func TestNioEncodedPath(t *testing.T) {
 	e := New()
 	e.GET("/:id", func(c Context) error {
@@ -403,6 +629,112 @@ func TestNioMethodNotAllowed(t *testing.T) {
 	rec := httptest.NewRecorder()
 	e.ServeHTTP(rec, req)
 	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get(HeaderAllow))
+}
+
+func TestNioAutoHead(t *testing.T) {
+	e := New()
+	e.GET("/users", func(c Context) error {
+		c.Response().Header().Set("X-Total", "2")
+		return c.String(http.StatusOK, "joe, jon")
+	})
+	e.GET("/files", func(c Context) error {
+		return c.String(http.StatusOK, "files")
+	}).NoAutoHead()
+	e.GET("/missing", func(c Context) error {
+		return ErrNotFound
+	})
+
+	assert := assert.New(t)
+
+	req := httptest.NewRequest(http.MethodHead, "/users", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal("8", rec.Header().Get(HeaderContentLength))
+	assert.Equal("2", rec.Header().Get("X-Total"))
+	assert.Equal(MIMETextPlainCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Empty(rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodHead, "/files", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal("GET", rec.Header().Get(HeaderAllow))
+
+	req = httptest.NewRequest(http.MethodHead, "/missing", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(http.StatusNotFound, rec.Code)
+	assert.Empty(rec.Body.String())
+}
+
+func TestNioAutoOptions(t *testing.T) {
+	h := func(c Context) error { return c.NoContent(http.StatusOK) }
+
+	e := New()
+	e.GET("/users/:id", h)
+	e.PUT("/users/:id", h)
+	code, _ := request(http.MethodOptions, "/users/1", e)
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	e = New(WithAutoOptions())
+	e.GET("/users/:id", h)
+	e.PUT("/users/:id", h)
+	e.Add("PURGE", "/users/:id", h)
+	e.OPTIONS("/custom", func(c Context) error { return c.NoContent(http.StatusTeapot) })
+
+	req := httptest.NewRequest(http.MethodOptions, "/users/1", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, PUT, PURGE", rec.Header().Get(HeaderAllow))
+
+	req = httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, PUT, PURGE", rec.Header().Get(HeaderAllow))
+
+	code, _ = request(http.MethodOptions, "/custom", e)
+	assert.Equal(t, http.StatusTeapot, code)
+	code, _ = request(http.MethodOptions, "/unknown", e)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestNioClientDisconnect(t *testing.T) {
+	var err error
+	e := New(WithHTTPErrorHandler(func(e error, c Context) {
+		err = e
+	}))
+	called := false
+	h := func(c Context) error {
+		called = true
+		return c.NoContent(http.StatusOK)
+	}
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	disconnect := func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			cancel()
+			return next(c)
+		}
+	}
+	e.GET("/", h, disconnect)
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.False(t, called)
+	assert.Equal(t, ErrClientClosedRequest, err)
+
+	// Already disconnected
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			called = true
+			return next(c)
+		}
+	})
+	e.ServeHTTP(rec, req)
+	assert.False(t, called)
 }
 
 func TestNioContext(t *testing.T) {
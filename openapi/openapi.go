@@ -0,0 +1,406 @@
+/*
+Package openapi generates OpenAPI 3.1 documents from the routes registered on
+a nio instance.
+
+Request and response types are attached to routes with `Types()`, the request
+type is inspected for path (`param` tag), query (`query` tag) and body (`json`
+or `form` tag) fields:
+
+	type getUser struct {
+	  ID int `param:"id"`
+	}
+
+	openapi.Types(n.GET("/users/:id", h), getUser{}, user{})
+	openapi.Register(n, openapi.Config{Title: "Users", Version: "1.0.0"})
+*/
+package openapi
+
+import (
+	"html/template"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-nio/nio"
+	"github.com/go-nio/nio/internal/route"
+)
+
+type (
+	// Config defines the config for the generated document and the routes
+	// serving it.
+	Config struct {
+		// Title of the API.
+		// Optional. Default value "API".
+		Title string `yaml:"title"`
+
+		// Version of the API.
+		// Optional. Default value "0.0.0".
+		Version string `yaml:"version"`
+
+		// Description of the API.
+		// Optional. Default value "".
+		Description string `yaml:"description"`
+
+		// Servers lists the base URLs of the API.
+		// Optional. Default value []string{}.
+		Servers []string `yaml:"servers"`
+
+		// Path of the route serving the JSON document.
+		// Optional. Default value "/openapi.json".
+		Path string `yaml:"path"`
+
+		// DocsPath of the route serving the docs page. Set it to "-" to disable
+		// the docs page.
+		// Optional. Default value "/docs".
+		DocsPath string `yaml:"docs_path"`
+	}
+
+	// Document is an OpenAPI document.
+	Document struct {
+		OpenAPI    string               `json:"openapi"`
+		Info       Info                 `json:"info"`
+		Servers    []Server             `json:"servers,omitempty"`
+		Paths      map[string]*PathItem `json:"paths"`
+		Components *Components          `json:"components,omitempty"`
+	}
+
+	// Info describes the API.
+	Info struct {
+		Title       string `json:"title"`
+		Version     string `json:"version"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// Server is a base URL of the API.
+	Server struct {
+		URL string `json:"url"`
+	}
+
+	// PathItem holds the operations of a path by lower case method.
+	PathItem map[string]*Operation
+
+	// Operation describes a route.
+	Operation struct {
+		OperationID string               `json:"operationId,omitempty"`
+		Summary     string               `json:"summary,omitempty"`
+		Tags        []string             `json:"tags,omitempty"`
+		Parameters  []*Parameter         `json:"parameters,omitempty"`
+		RequestBody *RequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*Response `json:"responses"`
+	}
+
+	// Parameter is a path or query parameter.
+	Parameter struct {
+		Name        string  `json:"name"`
+		In          string  `json:"in"`
+		Required    bool    `json:"required,omitempty"`
+		Description string  `json:"description,omitempty"`
+		Schema      *Schema `json:"schema"`
+	}
+
+	// RequestBody describes the request body by media type.
+	RequestBody struct {
+		Required bool                  `json:"required,omitempty"`
+		Content  map[string]*MediaType `json:"content"`
+	}
+
+	// Response describes a response by media type.
+	Response struct {
+		Description string                `json:"description"`
+		Content     map[string]*MediaType `json:"content,omitempty"`
+	}
+
+	// MediaType holds the schema of a request or response body.
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	// Components holds the schemas of named types.
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas,omitempty"`
+	}
+)
+
+// Route metadata keys
+const (
+	MetaRequest  = "openapi.request"
+	MetaResponse = "openapi.response"
+	MetaExclude  = "openapi.exclude"
+)
+
+// wildcardParam is the name of the path parameter of the `*` wildcard.
+const wildcardParam = "path"
+
+// identifier matches the names of custom param matchers.
+var identifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+var (
+	// DefaultConfig is the default OpenAPI config.
+	DefaultConfig = Config{
+		Title:    "API",
+		Version:  "0.0.0",
+		Path:     "/openapi.json",
+		DocsPath: "/docs",
+	}
+
+	// Methods supported by OpenAPI path items
+	methods = map[string]bool{
+		http.MethodGet:     true,
+		http.MethodPut:     true,
+		http.MethodPost:    true,
+		http.MethodDelete:  true,
+		http.MethodOptions: true,
+		http.MethodHead:    true,
+		http.MethodPatch:   true,
+		http.MethodTrace:   true,
+	}
+
+	// Body is documented for these methods only
+	bodyMethods = map[string]bool{
+		http.MethodPost:  true,
+		http.MethodPut:   true,
+		http.MethodPatch: true,
+	}
+)
+
+// Types attaches the request and response types to the route. Pass nil for a
+// route without request type or response body.
+func Types(r *nio.Route, request, response interface{}) *nio.Route {
+	return r.SetMeta(MetaRequest, request).SetMeta(MetaResponse, response)
+}
+
+// Exclude excludes the route from the generated document.
+func Exclude(r *nio.Route) *nio.Route {
+	return r.SetMeta(MetaExclude, true)
+}
+
+// Generate generates an OpenAPI document from the routes of the nio instance.
+func Generate(e *nio.Nio, config Config) *Document {
+	config = withDefaults(config)
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info: Info{
+			Title:       config.Title,
+			Version:     config.Version,
+			Description: config.Description,
+		},
+		Paths: map[string]*PathItem{},
+	}
+	for _, s := range config.Servers {
+		doc.Servers = append(doc.Servers, Server{URL: s})
+	}
+
+	g := newSchemaGenerator()
+	for _, r := range e.Routes() {
+		if !methods[r.Method] || r.GetMeta(MetaExclude) == true {
+			continue
+		}
+		path, params := convertPath(r.Path)
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(r.Method)] = operation(g, r, params)
+	}
+
+	if len(g.components) > 0 {
+		doc.Components = &Components{Schemas: g.components}
+	}
+	return doc
+}
+
+// Register registers the routes serving the generated document as JSON and the
+// docs page. The document is generated on each request, so it includes routes
+// registered afterwards.
+func Register(e *nio.Nio, config Config) {
+	config = withDefaults(config)
+	Exclude(e.GET(config.Path, func(c nio.Context) error {
+		return c.JSON(http.StatusOK, Generate(e, config))
+	}))
+	if config.DocsPath == "-" {
+		return
+	}
+	Exclude(e.GET(config.DocsPath, func(c nio.Context) error {
+		c.Response().Header().Set(nio.HeaderContentType, nio.MIMETextHTMLCharsetUTF8)
+		c.Response().WriteHeader(http.StatusOK)
+		return docsTemplate.Execute(c.Response(), map[string]interface{}{
+			"Doc":  Generate(e, config),
+			"Path": config.Path,
+		})
+	}))
+}
+
+func withDefaults(config Config) Config {
+	if config.Title == "" {
+		config.Title = DefaultConfig.Title
+	}
+	if config.Version == "" {
+		config.Version = DefaultConfig.Version
+	}
+	if config.Path == "" {
+		config.Path = DefaultConfig.Path
+	}
+	if config.DocsPath == "" {
+		config.DocsPath = DefaultConfig.DocsPath
+	}
+	return config
+}
+
+func operation(g *schemaGenerator, r *nio.Route, params []*Parameter) *Operation {
+	op := &Operation{
+		Summary:   r.Description,
+		Tags:      r.Tags,
+		Responses: map[string]*Response{},
+	}
+	if name := r.Name; name != "" && !strings.ContainsAny(name, "/(") && !strings.Contains(name, ".func") {
+		// Names of anonymous functions and methods are not useful as id
+		op.OperationID = name
+	}
+
+	if req := r.GetMeta(MetaRequest); req != nil {
+		t := reflect.TypeOf(req)
+		for _, f := range fields(t, "param") {
+			for _, p := range params {
+				if p.Name == f.name && p.Schema.Pattern == "" && p.Schema.Format == "" {
+					p.Schema = g.schema(f.typ)
+				}
+			}
+		}
+		for _, f := range fields(t, "query") {
+			params = append(params, &Parameter{Name: f.name, In: "query", Schema: g.schema(f.typ)})
+		}
+		if bodyMethods[r.Method] {
+			op.RequestBody = requestBody(g, t)
+		}
+	}
+	op.Parameters = params
+
+	if resp := r.GetMeta(MetaResponse); resp != nil {
+		op.Responses["200"] = &Response{
+			Description: http.StatusText(http.StatusOK),
+			Content: map[string]*MediaType{
+				nio.MIMEApplicationJSON: {Schema: g.schema(reflect.TypeOf(resp))},
+			},
+		}
+	} else {
+		// Nothing is known about the response
+		op.Responses["default"] = &Response{Description: "Undocumented response"}
+	}
+	return op
+}
+
+func requestBody(g *schemaGenerator, t reflect.Type) *RequestBody {
+	content := map[string]*MediaType{}
+	if form := fields(t, "form"); len(form) > 0 {
+		content[nio.MIMEApplicationForm] = &MediaType{Schema: g.object(t, "form")}
+	}
+	if len(fields(t, "json")) > 0 {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Name() != "" && len(fields(t, "param"))+len(fields(t, "query"))+len(fields(t, "form")) == 0 {
+			// Whole type is the body, reference it
+			content[nio.MIMEApplicationJSON] = &MediaType{Schema: g.schema(t)}
+		} else {
+			content[nio.MIMEApplicationJSON] = &MediaType{Schema: g.object(t, "json")}
+		}
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	return &RequestBody{Required: true, Content: content}
+}
+
+// convertPath converts a nio route path to an OpenAPI path template, returning
+// its path parameters. `:name` becomes `{name}` and the `*` wildcard `{path}`.
+func convertPath(path string) (string, []*Parameter) {
+	var (
+		b      strings.Builder
+		params []*Parameter
+	)
+	for i, l := 0, len(path); i < l; i++ {
+		switch path[i] {
+		case ':':
+			j := i + 1
+			for ; i < l && path[i] != '/' && path[i] != '<'; i++ {
+			}
+			name := path[j:i]
+			constraint := ""
+			if i < l && path[i] == '<' {
+				if k := route.ConstraintEnd(path, i); k != -1 {
+					constraint = path[i+1 : k]
+					i = k + 1
+				}
+			}
+			params = append(params, &Parameter{Name: name, In: "path", Required: true, Schema: constraintSchema(constraint)})
+			b.WriteString("{" + name + "}")
+			i-- // Continue with the character following the param
+		case '*':
+			params = append(params, &Parameter{
+				Name:        wildcardParam,
+				In:          "path",
+				Required:    true,
+				Description: "Rest of the path",
+				Schema:      &Schema{Type: "string"},
+			})
+			b.WriteString("{" + wildcardParam + "}")
+		default:
+			b.WriteByte(path[i])
+		}
+	}
+	return b.String(), params
+}
+
+// constraintSchema returns the schema of a path param with the constraint.
+func constraintSchema(constraint string) *Schema {
+	switch constraint {
+	case "":
+		return &Schema{Type: "string"}
+	case "int":
+		return &Schema{Type: "integer"}
+	case "uint":
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case "alpha":
+		return &Schema{Type: "string", Pattern: "^[a-zA-Z]+$"}
+	case "alnum":
+		return &Schema{Type: "string", Pattern: "^[a-zA-Z0-9]+$"}
+	case "uuid":
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+	if identifier.MatchString(constraint) {
+		// Custom matcher, e.g. `slug`, which can't be described
+		return &Schema{Type: "string"}
+	}
+	// Regular expressions are patterns
+	return &Schema{Type: "string", Pattern: "^(?:" + constraint + ")$"}
+}
+
+var docsTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Doc.Info.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 960px; color: #333; }
+.op { border: 1px solid #ddd; border-radius: 4px; margin: 1em 0; padding: .5em 1em; }
+.method { font-weight: bold; text-transform: uppercase; margin-right: .5em; }
+code { background: #f5f5f5; padding: 0 .2em; }
+</style>
+</head>
+<body>
+<h1>{{.Doc.Info.Title}} <small>{{.Doc.Info.Version}}</small></h1>
+{{with .Doc.Info.Description}}<p>{{.}}</p>{{end}}
+<p><a href="{{.Path}}">OpenAPI document</a></p>
+{{range $path, $item := .Doc.Paths}}{{range $method, $op := $item}}
+<div class="op">
+<p><span class="method">{{$method}}</span><code>{{$path}}</code> {{$op.Summary}}</p>
+{{with $op.Tags}}<p>Tags: {{range .}}<code>{{.}}</code> {{end}}</p>{{end}}
+{{with $op.Parameters}}<ul>{{range .}}<li><code>{{.Name}}</code> in {{.In}}{{with .Schema.Type}} ({{.}}){{end}}</li>{{end}}</ul>{{end}}
+</div>
+{{end}}{{end}}
+</body>
+</html>
+`))
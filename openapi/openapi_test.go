@@ -0,0 +1,165 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-nio/nio"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	user struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email,omitempty"`
+	}
+
+	getUser struct {
+		ID int `param:"id"`
+	}
+
+	listUsers struct {
+		Limit int    `query:"limit"`
+		Sort  string `query:"sort"`
+	}
+
+	updateUser struct {
+		ID   int    `param:"id"`
+		Name string `json:"name"`
+	}
+)
+
+func handler(c nio.Context) error {
+	return c.NoContent(http.StatusOK)
+}
+
+func TestConvertPath(t *testing.T) {
+	path, params := convertPath("/users/:id<int>/files/*")
+	assert.Equal(t, "/users/{id}/files/{path}", path)
+	if assert.Len(t, params, 2) {
+		assert.Equal(t, "id", params[0].Name)
+		assert.Equal(t, "path", params[0].In)
+		assert.True(t, params[0].Required)
+		assert.Equal(t, "integer", params[0].Schema.Type)
+		assert.Equal(t, "path", params[1].Name)
+		assert.Equal(t, "path", params[1].In)
+	}
+
+	path, params = convertPath("/a/:x<[a-z]{2}>/:y")
+	assert.Equal(t, "/a/{x}/{y}", path)
+	if assert.Len(t, params, 2) {
+		assert.Equal(t, "^(?:[a-z]{2})$", params[0].Schema.Pattern)
+		assert.Equal(t, "y", params[1].Name)
+		assert.Equal(t, "string", params[1].Schema.Type)
+	}
+
+	// Custom matchers can't be described
+	_, params = convertPath("/p/:s<slug>")
+	assert.Equal(t, &Schema{Type: "string"}, params[0].Schema)
+
+	_, params = convertPath("/:id<uuid>")
+	assert.Equal(t, "uuid", params[0].Schema.Format)
+}
+
+func TestGenerate(t *testing.T) {
+	e := nio.New()
+	Types(e.GET("/users", handler), listUsers{}, []user{}).AddTags("users")
+	Types(e.GET("/users/:id", handler), getUser{}, user{}).SetName("getUser").SetDescription("Get a user")
+	Types(e.PUT("/users/:id", handler), updateUser{}, nil)
+	Types(e.POST("/users", handler), user{}, user{})
+	Exclude(e.GET("/internal", handler))
+
+	doc := Generate(e, Config{Title: "Users", Servers: []string{"https://api.example.com"}})
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, "Users", doc.Info.Title)
+	assert.Equal(t, "0.0.0", doc.Info.Version)
+	assert.Equal(t, []Server{{URL: "https://api.example.com"}}, doc.Servers)
+	assert.NotContains(t, doc.Paths, "/internal")
+
+	// Query params and array response
+	list := (*doc.Paths["/users"])["get"]
+	if assert.NotNil(t, list) {
+		assert.Equal(t, []string{"users"}, list.Tags)
+		assert.Empty(t, list.OperationID)
+		if assert.Len(t, list.Parameters, 2) {
+			assert.Equal(t, "limit", list.Parameters[0].Name)
+			assert.Equal(t, "query", list.Parameters[0].In)
+			assert.Equal(t, "integer", list.Parameters[0].Schema.Type)
+		}
+		s := list.Responses["200"].Content[nio.MIMEApplicationJSON].Schema
+		assert.Equal(t, "array", s.Type)
+		assert.Equal(t, "#/components/schemas/user", s.Items.Ref)
+	}
+
+	// Path param typed from the request type
+	get := (*doc.Paths["/users/{id}"])["get"]
+	if assert.NotNil(t, get) {
+		assert.Equal(t, "getUser", get.OperationID)
+		assert.Equal(t, "Get a user", get.Summary)
+		if assert.Len(t, get.Parameters, 1) {
+			assert.Equal(t, "integer", get.Parameters[0].Schema.Type)
+		}
+		assert.Nil(t, get.RequestBody)
+	}
+
+	// Body without the path param, undocumented response
+	put := (*doc.Paths["/users/{id}"])["put"]
+	if assert.NotNil(t, put) {
+		s := put.RequestBody.Content[nio.MIMEApplicationJSON].Schema
+		assert.Equal(t, "object", s.Type)
+		assert.Contains(t, s.Properties, "name")
+		assert.NotContains(t, s.Properties, "ID")
+		assert.Contains(t, put.Responses, "default")
+		assert.NotContains(t, put.Responses, "204")
+	}
+
+	// Named body type is referenced
+	post := (*doc.Paths["/users"])["post"]
+	if assert.NotNil(t, post) {
+		assert.Equal(t, "#/components/schemas/user", post.RequestBody.Content[nio.MIMEApplicationJSON].Schema.Ref)
+	}
+
+	if assert.NotNil(t, doc.Components) {
+		s := doc.Components.Schemas["user"]
+		assert.Equal(t, []string{"id", "name"}, s.Required)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	e := nio.New()
+	Register(e, DefaultConfig)
+	Types(e.GET("/users/:id", handler), getUser{}, user{})
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	doc := map[string]interface{}{}
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc)) {
+		assert.Equal(t, "3.1.0", doc["openapi"])
+		paths := doc["paths"].(map[string]interface{})
+		assert.Contains(t, paths, "/users/{id}")
+		assert.NotContains(t, paths, "/openapi.json")
+		assert.NotContains(t, paths, "/docs")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/docs", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, nio.MIMETextHTMLCharsetUTF8, rec.Header().Get(nio.HeaderContentType))
+	assert.True(t, strings.Contains(rec.Body.String(), "/users/{id}"))
+	assert.True(t, strings.Contains(rec.Body.String(), `href="/openapi.json"`))
+
+	// Docs page disabled
+	e = nio.New()
+	Register(e, Config{DocsPath: "-"})
+	req = httptest.NewRequest(http.MethodGet, "/docs", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,187 @@
+package openapi
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Schema is an OpenAPI schema object, a subset of JSON Schema.
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	}
+
+	// schemaGenerator derives schemas from Go types. Named struct types are
+	// added to the components and referenced, which also handles recursive
+	// types.
+	schemaGenerator struct {
+		components map[string]*Schema
+		names      map[reflect.Type]string
+	}
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		components: map[string]*Schema{},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+// schema returns the schema of t.
+func (g *schemaGenerator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() != reflect.Struct && reflect.PtrTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64: // int is 64-bit on supported platforms
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint8, reflect.Uint16:
+		zero := 0.0
+		return &Schema{Type: "integer", Format: "int32", Minimum: &zero}
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Format: "int64", Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t, "json")
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	}
+	// Interfaces and unsupported kinds allow any value
+	return &Schema{}
+}
+
+// component adds the schema of a named struct type to the components and
+// returns its name.
+func (g *schemaGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	for i := 2; g.components[name] != nil; i++ {
+		// Same name in different packages
+		name = t.Name() + strconv.Itoa(i)
+	}
+	g.names[t] = name
+	g.components[name] = &Schema{} // Placeholder for recursive types
+	*g.components[name] = *g.object(t, "json")
+	return name
+}
+
+// object returns the object schema of the struct fields with the tag.
+func (g *schemaGenerator) object(t reflect.Type, tag string) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(s, t, tag)
+	return s
+}
+
+func (g *schemaGenerator) fields(s *Schema, t reflect.Type, tag string) {
+	for _, f := range fields(t, tag) {
+		s.Properties[f.name] = g.schema(f.typ)
+		if f.required {
+			s.Required = append(s.Required, f.name)
+		}
+	}
+}
+
+// boundElsewhere reports whether a field without json tag is bound from path
+// params, query params or a form instead of a JSON body.
+func boundElsewhere(sf reflect.StructField) bool {
+	for _, tag := range []string{"param", "query", "form"} {
+		if _, ok := sf.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type field struct {
+	name     string
+	typ      reflect.Type
+	required bool
+}
+
+// fields returns the exported fields of struct t named after the tag,
+// flattening embedded structs. For the json tag, fields without a tag use the
+// field name unless bound from elsewhere, and fields without omitempty are
+// required. For other tags only tagged fields are returned.
+func fields(t reflect.Type, tag string) []field {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var fs []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue // Unexported
+		}
+		value, tagged := sf.Tag.Lookup(tag)
+		name, opts := value, ""
+		if i := strings.IndexByte(value, ','); i != -1 {
+			name, opts = value[:i], value[i+1:]
+		}
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" {
+			fs = append(fs, fields(sf.Type, tag)...)
+			continue
+		}
+		if !tagged && (tag != "json" || boundElsewhere(sf)) {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fs = append(fs, field{
+			name:     name,
+			typ:      sf.Type,
+			required: tag == "json" && !strings.Contains(opts, "omitempty"),
+		})
+	}
+	return fs
+}
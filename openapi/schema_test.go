@@ -0,0 +1,92 @@
+package openapi
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	node struct {
+		Value    string  `json:"value"`
+		Children []*node `json:"children,omitempty"`
+	}
+
+	base struct {
+		Created time.Time `json:"created"`
+	}
+
+	item struct {
+		base
+		Name    string            `json:"name"`
+		Price   float64           `json:"price"`
+		Count   uint              `json:"count,omitempty"`
+		Labels  map[string]string `json:"labels,omitempty"`
+		Data    []byte            `json:"data,omitempty"`
+		IP      net.IP            `json:"ip,omitempty"`
+		Any     interface{}       `json:"any,omitempty"`
+		Ignored string            `json:"-"`
+		Page    int               `query:"page"`
+		secret  string
+	}
+)
+
+func TestSchema(t *testing.T) {
+	g := newSchemaGenerator()
+	s := g.schema(reflect.TypeOf(&item{}))
+	assert.Equal(t, "#/components/schemas/item", s.Ref)
+
+	s = g.components["item"]
+	assert.Equal(t, "object", s.Type)
+	assert.Equal(t, []string{"created", "name", "price"}, s.Required)
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, s.Properties["created"])
+	assert.Equal(t, &Schema{Type: "number", Format: "double"}, s.Properties["price"])
+	assert.Equal(t, 0.0, *s.Properties["count"].Minimum)
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, s.Properties["labels"])
+	assert.Equal(t, &Schema{Type: "string", Format: "byte"}, s.Properties["data"])
+	assert.Equal(t, &Schema{Type: "string"}, s.Properties["ip"])
+	assert.Equal(t, &Schema{}, s.Properties["any"])
+	assert.NotContains(t, s.Properties, "Ignored")
+	assert.NotContains(t, s.Properties, "-")
+	assert.NotContains(t, s.Properties, "Page")
+	assert.NotContains(t, s.Properties, "secret")
+}
+
+func TestSchemaRecursive(t *testing.T) {
+	g := newSchemaGenerator()
+	s := g.schema(reflect.TypeOf(node{}))
+	assert.Equal(t, "#/components/schemas/node", s.Ref)
+	assert.Equal(t, "#/components/schemas/node", g.components["node"].Properties["children"].Items.Ref)
+	assert.Len(t, g.components, 1)
+}
+
+func TestSchemaAnonymous(t *testing.T) {
+	g := newSchemaGenerator()
+	s := g.schema(reflect.TypeOf(struct {
+		A bool
+		B int64 `json:"b,omitempty"`
+		C int
+		D int32
+		E uint
+	}{}))
+	assert.Equal(t, "object", s.Type)
+	assert.Equal(t, &Schema{Type: "boolean"}, s.Properties["A"])
+	assert.Equal(t, &Schema{Type: "integer", Format: "int64"}, s.Properties["b"])
+	assert.Equal(t, &Schema{Type: "integer", Format: "int64"}, s.Properties["C"])
+	assert.Equal(t, &Schema{Type: "integer", Format: "int32"}, s.Properties["D"])
+	assert.Equal(t, "int64", s.Properties["E"].Format)
+	assert.Equal(t, []string{"A", "C", "D", "E"}, s.Required)
+	assert.Empty(t, g.components)
+}
+
+func TestFields(t *testing.T) {
+	fs := fields(reflect.TypeOf(item{}), "query")
+	if assert.Len(t, fs, 1) {
+		assert.Equal(t, "page", fs[0].name)
+		assert.False(t, fs[0].required)
+	}
+	assert.Nil(t, fields(reflect.TypeOf(""), "json"))
+}
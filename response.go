@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"net"
 	"net/http"
+	"strconv"
 )
 
 type (
@@ -98,6 +99,60 @@ func (r *Response) CloseNotify() <-chan bool {
 	return r.Writer.(http.CloseNotifier).CloseNotify()
 }
 
+// headResponseWriter discards the body written by a GET handler serving a HEAD
+// request. Sending the header is delayed until the handler returns so
+// Content-Length can be set to the size of the discarded body.
+type headResponseWriter struct {
+	http.ResponseWriter
+	code        int
+	size        int
+	wroteHeader bool
+}
+
+func (w *headResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	w.size += len(b)
+	return len(b), nil
+}
+
+// Flush sends the header without Content-Length, e.g. for streamed responses.
+func (w *headResponseWriter) Flush() {
+	w.writeHeader()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *headResponseWriter) finish() {
+	if w.code == 0 || w.wroteHeader {
+		return
+	}
+	if w.code >= http.StatusOK && w.code != http.StatusNoContent && w.code != http.StatusNotModified &&
+		w.Header().Get(HeaderContentLength) == "" {
+		w.Header().Set(HeaderContentLength, strconv.Itoa(w.size))
+	}
+	w.writeHeader()
+}
+
+func (w *headResponseWriter) writeHeader() {
+	if w.wroteHeader {
+		return
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	w.wroteHeader = true
+}
+
 func (r *Response) reset(w http.ResponseWriter) {
 	r.beforeFuncs = nil
 	r.afterFuncs = nil
@@ -1,14 +1,31 @@
 package nio
 
-import "net/http"
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-nio/nio/internal/route"
+)
 
 type (
 	// router is the registry of all registered routes for an `Nio` instance for
 	// request matching and URL path parameter parsing.
 	router struct {
-		tree   *node
-		routes map[string]*Route
-		nio    *Nio
+		tree    *node
+		routes  map[string]*Route
+		list    []*Route          // Routes in registration order
+		names   map[string]*Route // Routes with an explicit name
+		methods []string          // Registered non-standard methods
+		nio     *Nio
+
+		// Routes by method and pattern, and by pattern, where the pattern is
+		// the path without param names, for conflict detection.
+		patterns map[string]*Route
+		shapes   map[string]*Route
 	}
 	node struct {
 		kind          kind
@@ -19,6 +36,8 @@ type (
 		ppath         string
 		pnames        []string
 		methodHandler *methodHandler
+		constraint    string
+		matcher       ParamMatcher
 	}
 	kind          uint8
 	children      []*node
@@ -33,6 +52,13 @@ type (
 		propfind HandlerFunc
 		put      HandlerFunc
 		trace    HandlerFunc
+		others   map[string]HandlerFunc // Non-standard methods, e.g. WebDAV
+
+		noAutoHead bool              // Don't answer HEAD with the GET handler
+		routes     map[string]*Route // Routes by method
+
+		allowed     []string // Methods with a handler
+		allowedAuto []string // Allowed methods including automatic OPTIONS
 	}
 )
 
@@ -42,19 +68,127 @@ const (
 	akind
 )
 
+// Built-in param matchers
+var paramMatchers = map[string]ParamMatcher{
+	"int": func(v string) bool {
+		if v != "" && v[0] == '-' {
+			v = v[1:]
+		}
+		return v != "" && strings.Trim(v, "0123456789") == ""
+	},
+	"uint": func(v string) bool {
+		return v != "" && strings.Trim(v, "0123456789") == ""
+	},
+	"alpha": func(v string) bool {
+		return v != "" && strings.Trim(v, alpha) == ""
+	},
+	"alnum": func(v string) bool {
+		return v != "" && strings.Trim(v, alpha+"0123456789") == ""
+	},
+	"uuid": regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$").MatchString,
+}
+
+const alpha = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
 // NewRouter returns a new Router instance.
 func newRouter(e *Nio) *router {
 	return &router{
 		tree: &node{
 			methodHandler: new(methodHandler),
 		},
-		routes: map[string]*Route{},
-		nio:    e,
+		routes:   map[string]*Route{},
+		names:    map[string]*Route{},
+		nio:      e,
+		patterns: map[string]*Route{},
+		shapes:   map[string]*Route{},
+	}
+}
+
+// conflict checks the route for conflicts with the registered routes before
+// it is added. Internal routes and routes registered with Any being replaced
+// are no conflicts.
+func (r *router) conflict(route *Route) error {
+	if route.hidden {
+		return nil
+	}
+	pattern := routePattern(route.Path)
+	if old, ok := r.patterns[route.Method+pattern]; ok && !old.overridable {
+		return &RouteConflictError{Route: route, Existing: old}
+	}
+	if old, ok := r.shapes[pattern]; ok && routePath(old.Path) != routePath(route.Path) {
+		return &RouteConflictError{Route: route, Existing: old}
+	}
+	r.patterns[route.Method+pattern] = route
+	if _, ok := r.shapes[pattern]; !ok {
+		r.shapes[pattern] = route
+	}
+	return nil
+}
+
+// routePattern returns the path without param names, e.g. `/users/:<int>` for
+// `/users/:id<int>`.
+func routePattern(path string) string {
+	path = routePath(path)
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		b.WriteByte(path[i])
+		if path[i] == ':' {
+			for i+1 < len(path) && path[i+1] != '/' && path[i+1] != '<' {
+				i++
+			}
+		}
 	}
+	return b.String()
 }
 
-// add registers a new route for method and path with matching handler.
-func (r *router) add(method, path string, h HandlerFunc) {
+func routePath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/" + path
+	}
+	return path
+}
+
+// addRoute records a route, replacing the one registered for the same method
+// and path.
+func (r *router) addRoute(route *Route) {
+	key := route.Method + route.Path
+	if old, ok := r.routes[key]; ok {
+		for i, o := range r.list {
+			if o == old {
+				r.list[i] = route
+				break
+			}
+		}
+		if r.names[old.Name] == old {
+			delete(r.names, old.Name)
+		}
+	} else {
+		r.list = append(r.list, route)
+	}
+	r.routes[key] = route
+}
+
+// named returns the route with an explicit name or the first route registered
+// with the name.
+func (r *router) named(name string) *Route {
+	if route, ok := r.names[name]; ok {
+		return route
+	}
+	for _, route := range r.list {
+		if route.Name == name {
+			return route
+		}
+	}
+	return nil
+}
+
+// add registers a new route for method and path with matching handler and
+// returns the method handler of the route node.
+func (r *router) add(method, path string, h HandlerFunc) *methodHandler {
+	// Validate method
+	if !validMethod(method) {
+		panic("nio: invalid method " + method)
+	}
 	// Validate path
 	if path == "" {
 		panic("nio: path cannot be empty")
@@ -62,38 +196,48 @@ func (r *router) add(method, path string, h HandlerFunc) {
 	if path[0] != '/' {
 		path = "/" + path
 	}
-	pnames := []string{} // Param names
-	ppath := path        // Pristine path
+	pnames := []string{}      // Param names
+	constraints := []string{} // Param constraints
+	ppath := path             // Pristine path
 
 	for i, l := 0, len(path); i < l; i++ {
 		if path[i] == ':' {
 			j := i + 1
 
-			r.insert(method, path[:i], nil, skind, "", nil)
-			for ; i < l && path[i] != '/'; i++ {
+			r.insert(method, path[:i], nil, skind, "", nil, constraints)
+			for ; i < l && path[i] != '/' && path[i] != '<'; i++ {
 			}
-
 			pnames = append(pnames, path[j:i])
+
+			constraint := ""
+			if i < l && path[i] == '<' {
+				k := route.ConstraintEnd(path, i)
+				if k == -1 || k+1 < l && path[k+1] != '/' {
+					panic("nio: invalid param constraint in path " + ppath)
+				}
+				constraint = path[i+1 : k]
+				i = k + 1
+			}
+			constraints = append(constraints, constraint)
+
 			path = path[:j] + path[i:]
 			i, l = j, len(path)
 
 			if i == l {
-				r.insert(method, path[:i], h, pkind, ppath, pnames)
-				return
+				return r.insert(method, path[:i], h, pkind, ppath, pnames, constraints)
 			}
-			r.insert(method, path[:i], nil, pkind, "", nil)
+			r.insert(method, path[:i], nil, pkind, "", nil, constraints)
 		} else if path[i] == '*' {
-			r.insert(method, path[:i], nil, skind, "", nil)
+			r.insert(method, path[:i], nil, skind, "", nil, constraints)
 			pnames = append(pnames, "*")
-			r.insert(method, path[:i+1], h, akind, ppath, pnames)
-			return
+			return r.insert(method, path[:i+1], h, akind, ppath, pnames, constraints)
 		}
 	}
 
-	r.insert(method, path, h, skind, ppath, pnames)
+	return r.insert(method, path, h, skind, ppath, pnames, constraints)
 }
 
-func (r *router) insert(method, path string, h HandlerFunc, t kind, ppath string, pnames []string) {
+func (r *router) insert(method, path string, h HandlerFunc, t kind, ppath string, pnames []string, constraints []string) *methodHandler {
 	// Adjust max param
 	l := len(pnames)
 	if *r.nio.maxParam < l {
@@ -105,6 +249,8 @@ func (r *router) insert(method, path string, h HandlerFunc, t kind, ppath string
 		panic("nio: invalid method")
 	}
 	search := path
+	pi := 0               // Param index
+	var mh *methodHandler // Method handler of the route node
 
 	for {
 		sl := len(search)
@@ -126,6 +272,7 @@ func (r *router) insert(method, path string, h HandlerFunc, t kind, ppath string
 			if h != nil {
 				cn.kind = t
 				cn.addHandler(method, h)
+				mh = cn.methodHandler
 				cn.ppath = ppath
 				cn.pnames = pnames
 			}
@@ -148,38 +295,61 @@ func (r *router) insert(method, path string, h HandlerFunc, t kind, ppath string
 				// At parent node
 				cn.kind = t
 				cn.addHandler(method, h)
+				mh = cn.methodHandler
 				cn.ppath = ppath
 				cn.pnames = pnames
 			} else {
 				// Create child node
 				n = newNode(t, search[l:], cn, nil, new(methodHandler), ppath, pnames)
+				r.constrain(n, constraints, pi)
 				n.addHandler(method, h)
+				mh = n.methodHandler
 				cn.addChild(n)
 			}
 		} else if l < sl {
 			search = search[l:]
-			c := cn.findChildWithLabel(search[0])
+			var c *node
+			if search[0] == ':' {
+				c = cn.findParamChild(constraints[pi])
+			} else {
+				c = cn.findChildWithLabel(search[0])
+			}
 			if c != nil {
 				// Go deeper
+				if c.label == ':' {
+					pi++
+				}
 				cn = c
 				continue
 			}
 			// Create child node
 			n := newNode(t, search, cn, nil, new(methodHandler), ppath, pnames)
+			r.constrain(n, constraints, pi)
 			n.addHandler(method, h)
+			mh = n.methodHandler
 			cn.addChild(n)
 		} else {
 			// Node already exists
 			if h != nil {
 				cn.addHandler(method, h)
+				mh = cn.methodHandler
 				cn.ppath = ppath
 				if len(cn.pnames) == 0 { // Issue #729
 					cn.pnames = pnames
 				}
 			}
 		}
+		return mh
+	}
+}
+
+// constrain sets the matcher of a new param node.
+func (r *router) constrain(n *node, constraints []string, pi int) {
+	if n.label != ':' || constraints[pi] == "" {
 		return
 	}
+	n.constraint = constraints[pi]
+	n.matcher = r.nio.paramMatcher(n.constraint)
 }
 
 func newNode(t kind, pre string, p *node, c children, mh *methodHandler, ppath string, pnames []string) *node {
@@ -195,8 +365,34 @@ func newNode(t kind, pre string, p *node, c children, mh *methodHandler, ppath s
 	}
 }
 
+// dump writes the node and its children indented by depth.
+func (n *node) dump(w io.Writer, depth int) {
+	fmt.Fprintf(w, "%s%s", strings.Repeat("  ", depth), n.prefix)
+	if n.constraint != "" {
+		fmt.Fprintf(w, "<%s>", n.constraint)
+	}
+	if mh := n.methodHandler; mh != nil && mh.isSet() {
+		fmt.Fprintf(w, " [%s] %s", strings.Join(mh.allowed, ", "), n.ppath)
+	}
+	fmt.Fprintln(w)
+	for _, c := range n.children {
+		c.dump(w, depth+1)
+	}
+}
+
 func (n *node) addChild(c *node) {
 	n.children = append(n.children, c)
+	if c.kind != pkind || c.matcher == nil {
+		return
+	}
+	// Constrained params are tried before the unconstrained one.
+	for i, p := range n.children {
+		if p.kind == pkind && p.matcher == nil {
+			copy(n.children[i+1:], n.children[i:])
+			n.children[i] = c
+			return
+		}
+	}
 }
 
 func (n *node) findChild(l byte, t kind) *node {
@@ -217,6 +413,15 @@ func (n *node) findChildWithLabel(l byte) *node {
 	return nil
 }
 
+func (n *node) findParamChild(constraint string) *node {
+	for _, c := range n.children {
+		if c.kind == pkind && c.constraint == constraint {
+			return c
+		}
+	}
+	return nil
+}
+
 func (n *node) findChildByKind(t kind) *node {
 	for _, c := range n.children {
 		if c.kind == t {
@@ -248,43 +453,178 @@ func (n *node) addHandler(method string, h HandlerFunc) {
 		n.methodHandler.put = h
 	case http.MethodTrace:
 		n.methodHandler.trace = h
+	default:
+		if h == nil {
+			return
+		}
+		if n.methodHandler.others == nil {
+			n.methodHandler.others = map[string]HandlerFunc{}
+		}
+		n.methodHandler.others[method] = h
+	}
+	n.methodHandler.updateAllowed()
+}
+
+// updateAllowed updates the allowed method sets used for the Allow header.
+func (h *methodHandler) updateAllowed() {
+	h.allowed, h.allowedAuto = nil, nil
+	for _, m := range methods {
+		if h.find(m) != nil || m == http.MethodHead && h.autoHead() != nil {
+			h.allowed = append(h.allowed, m)
+			h.allowedAuto = append(h.allowedAuto, m)
+		} else if m == http.MethodOptions {
+			h.allowedAuto = append(h.allowedAuto, m)
+		}
+	}
+	others := make([]string, 0, len(h.others))
+	for m := range h.others {
+		others = append(others, m)
+	}
+	sort.Strings(others)
+	h.allowed = append(h.allowed, others...)
+	h.allowedAuto = append(h.allowedAuto, others...)
+	if len(h.allowed) == 0 {
+		h.allowedAuto = nil
 	}
 }
 
 func (n *node) findHandler(method string) HandlerFunc {
+	return n.methodHandler.find(method)
+}
+
+func (h *methodHandler) find(method string) HandlerFunc {
 	switch method {
 	case http.MethodConnect:
-		return n.methodHandler.connect
+		return h.connect
 	case http.MethodDelete:
-		return n.methodHandler.delete
+		return h.delete
 	case http.MethodGet:
-		return n.methodHandler.get
+		return h.get
 	case http.MethodHead:
-		return n.methodHandler.head
+		if h.headReplaced() {
+			return nil
+		}
+		return h.head
 	case http.MethodOptions:
-		return n.methodHandler.options
+		return h.options
 	case http.MethodPatch:
-		return n.methodHandler.patch
+		return h.patch
 	case http.MethodPost:
-		return n.methodHandler.post
+		return h.post
 	case PROPFIND:
-		return n.methodHandler.propfind
+		return h.propfind
 	case http.MethodPut:
-		return n.methodHandler.put
+		return h.put
 	case http.MethodTrace:
-		return n.methodHandler.trace
+		return h.trace
 	default:
+		return h.others[method]
+	}
+}
+
+func (h *methodHandler) isSet() bool {
+	return len(h.allowed) > 0
+}
+
+func (h *methodHandler) setRoute(method string, r *Route) {
+	if h.routes == nil {
+		h.routes = map[string]*Route{}
+	}
+	h.routes[method] = r
+	h.updateAllowed()
+}
+
+// autoHead returns the GET handler used for HEAD requests.
+func (h *methodHandler) autoHead() HandlerFunc {
+	if h.noAutoHead || h.head != nil && !h.headReplaced() {
 		return nil
 	}
+	return h.get
+}
+
+// headReplaced reports whether the HEAD handler of a hidden or Any route gives
+// way to the handler of a GET route, e.g. a group catch-all.
+func (h *methodHandler) headReplaced() bool {
+	return h.head != nil && h.get != nil && !h.noAutoHead &&
+		h.replaceable(http.MethodHead) && !h.replaceable(http.MethodGet)
+}
+
+// replaceable reports whether the route of the method is hidden or registered
+// with Any.
+func (h *methodHandler) replaceable(method string) bool {
+	r := h.routes[method]
+	return r != nil && (r.hidden || r.overridable)
+}
+
+// fallback returns the handler for a method without a route, reporting whether
+// it is the GET handler answering a HEAD request.
+func (n *node) fallback(method string, autoOptions bool) (HandlerFunc, bool) {
+	if method == http.MethodHead {
+		if h := n.methodHandler.autoHead(); h != nil {
+			return h, true
+		}
+	}
+	return n.checkMethodNotAllowed(method, autoOptions), false
+}
+
+func (n *node) checkMethodNotAllowed(method string, autoOptions bool) HandlerFunc {
+	if !n.methodHandler.isSet() {
+		return NotFoundHandler
+	}
+	if autoOptions && method == http.MethodOptions {
+		return OptionsHandler
+	}
+	return MethodNotAllowedHandler
+}
+
+// allowedMethods returns the methods allowed for the node.
+func (n *node) allowedMethods(autoOptions bool) []string {
+	if autoOptions {
+		return n.methodHandler.allowedAuto
+	}
+	return n.methodHandler.allowed
 }
 
-func (n *node) checkMethodNotAllowed() HandlerFunc {
+// addMethod records a non-standard method and reports whether it was not
+// registered before.
+func (r *router) addMethod(method string) bool {
+	if standardMethod(method) {
+		return false
+	}
+	for _, m := range r.methods {
+		if m == method {
+			return false
+		}
+	}
+	r.methods = append(r.methods, method)
+	return true
+}
+
+func standardMethod(method string) bool {
 	for _, m := range methods {
-		if h := n.findHandler(m); h != nil {
-			return MethodNotAllowedHandler
+		if m == method {
+			return true
 		}
 	}
-	return NotFoundHandler
+	return false
+}
+
+// validMethod reports whether method is a valid token as defined in RFC 7230,
+// section 3.2.6.
+func validMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for i := 0; i < len(method); i++ {
+		c := method[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			continue
+		}
+		if !strings.ContainsRune("!#$%&'*+-.^_`|~", rune(c)) {
+			return false
+		}
+	}
+	return true
 }
 
 // Find lookup a handler registered for method and path. It also parses URL for path
@@ -298,138 +638,97 @@ func (n *node) checkMethodNotAllowed() HandlerFunc {
 func (r *router) find(method, path string, c Context) {
 	ctx := c.(*context)
 	ctx.path = path
-	cn := r.tree // Current node as root
+	pvalues := ctx.pvalues // Use the internal slice so the interface can keep the illusion of a dynamic slice
 
-	var (
-		search  = path
-		child   *node         // Child node
-		n       int           // Param counter
-		nk      kind          // Next kind
-		nn      *node         // Next node
-		ns      string        // Next search
-		pvalues = ctx.pvalues // Use the internal slice so the interface can keep the illusion of a dynamic slice
-	)
-
-	// Search order static > param > any
-	for {
-		if search == "" {
-			break
-		}
+	cn := r.tree // Current node as root
+	if len(path) < len(cn.prefix) || path[:len(cn.prefix)] != cn.prefix {
+		// Not found
+		return
+	}
+	if cn = cn.match(path[len(cn.prefix):], 0, pvalues); cn == nil {
+		// Not found
+		return
+	}
 
-		pl := 0 // Prefix length
-		l := 0  // LCP length
+	auto := r.nio.autoOptions
+	ctx.handler = cn.findHandler(method)
+	ctx.path = cn.ppath
+	ctx.pnames = cn.pnames
+	ctx.allowed = cn.allowedMethods(auto)
+	ctx.methodHandler = cn.methodHandler
+	ctx.routeMethod = method
 
-		if cn.label != ':' {
-			sl := len(search)
-			pl = len(cn.prefix)
+	// NOTE: Slow zone...
+	if ctx.handler == nil {
+		var head bool
+		ctx.handler, head = cn.fallback(method, auto)
 
-			// LCP
-			max := pl
-			if sl < max {
-				max = sl
-			}
-			for ; l < max && search[l] == cn.prefix[l]; l++ {
+		// Dig further for any, might have an empty value for *, e.g.
+		// serving a directory. Issue #207. A HEAD request answered by the GET
+		// handler of the node stays on the node.
+		if cn = cn.findChildByKind(akind); cn != nil && !head {
+			if h := cn.findHandler(method); h != nil {
+				ctx.handler, head = h, false
+			} else {
+				ctx.handler, head = cn.fallback(method, auto)
 			}
+			ctx.path = cn.ppath
+			ctx.pnames = cn.pnames
+			ctx.allowed = cn.allowedMethods(auto)
+			ctx.methodHandler = cn.methodHandler
+			pvalues[len(cn.pnames)-1] = ""
 		}
 
-		if l == pl {
-			// Continue search
-			search = search[l:]
-		} else {
-			cn = nn
-			search = ns
-			if nk == pkind {
-				goto Param
-			} else if nk == akind {
-				goto Any
-			}
-			// Not found
-			return
+		if head {
+			ctx.routeMethod = http.MethodGet
+			// Serve HEAD with the GET handler, discarding the body
+			ctx.head = &headResponseWriter{ResponseWriter: ctx.response.Writer}
+			ctx.response.Writer = ctx.head
 		}
+	}
+}
 
-		if search == "" {
-			break
+// match returns the node matching search, the part of the path following the
+// prefix of n, and loads param values into pvalues starting at index p. Search
+// order is static > param > any, falling back to the next kind if the subtree
+// doesn't match, e.g. because of a failed param constraint.
+func (n *node) match(search string, p int, pvalues []string) *node {
+	if search == "" {
+		if n.methodHandler.isSet() || n.findChildByKind(akind) != nil {
+			return n
 		}
+		return nil
+	}
 
-		// Static node
-		if child = cn.findChild(search[0], skind); child != nil {
-			// Save next
-			if cn.prefix[len(cn.prefix)-1] == '/' { // Issue #623
-				nk = pkind
-				nn = cn
-				ns = search
+	// Static node
+	if c := n.findChild(search[0], skind); c != nil {
+		if pl := len(c.prefix); len(search) >= pl && search[:pl] == c.prefix {
+			if m := c.match(search[pl:], p, pvalues); m != nil {
+				return m
 			}
-			cn = child
-			continue
 		}
+	}
 
-		// Param node
-	Param:
-		if child = cn.findChildByKind(pkind); child != nil {
-			// Issue #378
-			if len(pvalues) == n {
+	// Param node
+	if p < len(pvalues) { // Issue #378
+		i, l := 0, len(search)
+		for ; i < l && search[i] != '/'; i++ {
+		}
+		for _, c := range n.children {
+			if c.kind != pkind || c.matcher != nil && !c.matcher(search[:i]) {
 				continue
 			}
-
-			// Save next
-			if cn.prefix[len(cn.prefix)-1] == '/' { // Issue #623
-				nk = akind
-				nn = cn
-				ns = search
-			}
-
-			cn = child
-			i, l := 0, len(search)
-			for ; i < l && search[i] != '/'; i++ {
+			pvalues[p] = search[:i]
+			if m := c.match(search[i:], p+1, pvalues); m != nil {
+				return m
 			}
-			pvalues[n] = search[:i]
-			n++
-			search = search[i:]
-			continue
-		}
-
-		// Any node
-	Any:
-		if cn = cn.findChildByKind(akind); cn == nil {
-			if nn != nil {
-				cn = nn
-				nn = cn.parent // Next (Issue #954)
-				search = ns
-				if nk == pkind {
-					goto Param
-				} else if nk == akind {
-					goto Any
-				}
-			}
-			// Not found
-			return
 		}
-		pvalues[len(cn.pnames)-1] = search
-		break
 	}
 
-	ctx.handler = cn.findHandler(method)
-	ctx.path = cn.ppath
-	ctx.pnames = cn.pnames
-
-	// NOTE: Slow zone...
-	if ctx.handler == nil {
-		ctx.handler = cn.checkMethodNotAllowed()
-
-		// Dig further for any, might have an empty value for *, e.g.
-		// serving a directory. Issue #207.
-		if cn = cn.findChildByKind(akind); cn == nil {
-			return
-		}
-		if h := cn.findHandler(method); h != nil {
-			ctx.handler = h
-		} else {
-			ctx.handler = cn.checkMethodNotAllowed()
-		}
-		ctx.path = cn.ppath
-		ctx.pnames = cn.pnames
-		pvalues[len(cn.pnames)-1] = ""
+	// Any node
+	if c := n.findChildByKind(akind); c != nil {
+		pvalues[len(c.pnames)-1] = search
+		return c
 	}
-
-	return
+	return nil
 }
@@ -3,6 +3,8 @@ package nio
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -11,493 +13,493 @@ import (
 
 var (
 	staticRoutes = []*Route{
-		{"GET", "/", ""},
-		{"GET", "/cmd.html", ""},
-		{"GET", "/code.html", ""},
-		{"GET", "/contrib.html", ""},
-		{"GET", "/contribute.html", ""},
-		{"GET", "/debugging_with_gdb.html", ""},
-		{"GET", "/docs.html", ""},
-		{"GET", "/effective_go.html", ""},
-		{"GET", "/files.log", ""},
-		{"GET", "/gccgo_contribute.html", ""},
-		{"GET", "/gccgo_install.html", ""},
-		{"GET", "/go-logo-black.png", ""},
-		{"GET", "/go-logo-blue.png", ""},
-		{"GET", "/go-logo-white.png", ""},
-		{"GET", "/go1.1.html", ""},
-		{"GET", "/go1.2.html", ""},
-		{"GET", "/go1.html", ""},
-		{"GET", "/go1compat.html", ""},
-		{"GET", "/go_faq.html", ""},
-		{"GET", "/go_mem.html", ""},
-		{"GET", "/go_spec.html", ""},
-		{"GET", "/help.html", ""},
-		{"GET", "/ie.css", ""},
-		{"GET", "/install-source.html", ""},
-		{"GET", "/install.html", ""},
-		{"GET", "/logo-153x55.png", ""},
-		{"GET", "/Makefile", ""},
-		{"GET", "/root.html", ""},
-		{"GET", "/share.png", ""},
-		{"GET", "/sieve.gif", ""},
-		{"GET", "/tos.html", ""},
-		{"GET", "/articles/", ""},
-		{"GET", "/articles/go_command.html", ""},
-		{"GET", "/articles/index.html", ""},
-		{"GET", "/articles/wiki/", ""},
-		{"GET", "/articles/wiki/edit.html", ""},
-		{"GET", "/articles/wiki/final-noclosure.go", ""},
-		{"GET", "/articles/wiki/final-noerror.go", ""},
-		{"GET", "/articles/wiki/final-parsetemplate.go", ""},
-		{"GET", "/articles/wiki/final-template.go", ""},
-		{"GET", "/articles/wiki/final.go", ""},
-		{"GET", "/articles/wiki/get.go", ""},
-		{"GET", "/articles/wiki/http-sample.go", ""},
-		{"GET", "/articles/wiki/index.html", ""},
-		{"GET", "/articles/wiki/Makefile", ""},
-		{"GET", "/articles/wiki/notemplate.go", ""},
-		{"GET", "/articles/wiki/part1-noerror.go", ""},
-		{"GET", "/articles/wiki/part1.go", ""},
-		{"GET", "/articles/wiki/part2.go", ""},
-		{"GET", "/articles/wiki/part3-errorhandling.go", ""},
-		{"GET", "/articles/wiki/part3.go", ""},
-		{"GET", "/articles/wiki/test.bash", ""},
-		{"GET", "/articles/wiki/test_edit.good", ""},
-		{"GET", "/articles/wiki/test_Test.txt.good", ""},
-		{"GET", "/articles/wiki/test_view.good", ""},
-		{"GET", "/articles/wiki/view.html", ""},
-		{"GET", "/codewalk/", ""},
-		{"GET", "/codewalk/codewalk.css", ""},
-		{"GET", "/codewalk/codewalk.js", ""},
-		{"GET", "/codewalk/codewalk.xml", ""},
-		{"GET", "/codewalk/functions.xml", ""},
-		{"GET", "/codewalk/markov.go", ""},
-		{"GET", "/codewalk/markov.xml", ""},
-		{"GET", "/codewalk/pig.go", ""},
-		{"GET", "/codewalk/popout.png", ""},
-		{"GET", "/codewalk/run", ""},
-		{"GET", "/codewalk/sharemem.xml", ""},
-		{"GET", "/codewalk/urlpoll.go", ""},
-		{"GET", "/devel/", ""},
-		{"GET", "/devel/release.html", ""},
-		{"GET", "/devel/weekly.html", ""},
-		{"GET", "/gopher/", ""},
-		{"GET", "/gopher/appenginegopher.jpg", ""},
-		{"GET", "/gopher/appenginegophercolor.jpg", ""},
-		{"GET", "/gopher/appenginelogo.gif", ""},
-		{"GET", "/gopher/bumper.png", ""},
-		{"GET", "/gopher/bumper192x108.png", ""},
-		{"GET", "/gopher/bumper320x180.png", ""},
-		{"GET", "/gopher/bumper480x270.png", ""},
-		{"GET", "/gopher/bumper640x360.png", ""},
-		{"GET", "/gopher/doc.png", ""},
-		{"GET", "/gopher/frontpage.png", ""},
-		{"GET", "/gopher/gopherbw.png", ""},
-		{"GET", "/gopher/gophercolor.png", ""},
-		{"GET", "/gopher/gophercolor16x16.png", ""},
-		{"GET", "/gopher/help.png", ""},
-		{"GET", "/gopher/pkg.png", ""},
-		{"GET", "/gopher/project.png", ""},
-		{"GET", "/gopher/ref.png", ""},
-		{"GET", "/gopher/run.png", ""},
-		{"GET", "/gopher/talks.png", ""},
-		{"GET", "/gopher/pencil/", ""},
-		{"GET", "/gopher/pencil/gopherhat.jpg", ""},
-		{"GET", "/gopher/pencil/gopherhelmet.jpg", ""},
-		{"GET", "/gopher/pencil/gophermega.jpg", ""},
-		{"GET", "/gopher/pencil/gopherrunning.jpg", ""},
-		{"GET", "/gopher/pencil/gopherswim.jpg", ""},
-		{"GET", "/gopher/pencil/gopherswrench.jpg", ""},
-		{"GET", "/play/", ""},
-		{"GET", "/play/fib.go", ""},
-		{"GET", "/play/hello.go", ""},
-		{"GET", "/play/life.go", ""},
-		{"GET", "/play/peano.go", ""},
-		{"GET", "/play/pi.go", ""},
-		{"GET", "/play/sieve.go", ""},
-		{"GET", "/play/solitaire.go", ""},
-		{"GET", "/play/tree.go", ""},
-		{"GET", "/progs/", ""},
-		{"GET", "/progs/cgo1.go", ""},
-		{"GET", "/progs/cgo2.go", ""},
-		{"GET", "/progs/cgo3.go", ""},
-		{"GET", "/progs/cgo4.go", ""},
-		{"GET", "/progs/defer.go", ""},
-		{"GET", "/progs/defer.out", ""},
-		{"GET", "/progs/defer2.go", ""},
-		{"GET", "/progs/defer2.out", ""},
-		{"GET", "/progs/eff_bytesize.go", ""},
-		{"GET", "/progs/eff_bytesize.out", ""},
-		{"GET", "/progs/eff_qr.go", ""},
-		{"GET", "/progs/eff_sequence.go", ""},
-		{"GET", "/progs/eff_sequence.out", ""},
-		{"GET", "/progs/eff_unused1.go", ""},
-		{"GET", "/progs/eff_unused2.go", ""},
-		{"GET", "/progs/error.go", ""},
-		{"GET", "/progs/error2.go", ""},
-		{"GET", "/progs/error3.go", ""},
-		{"GET", "/progs/error4.go", ""},
-		{"GET", "/progs/go1.go", ""},
-		{"GET", "/progs/gobs1.go", ""},
-		{"GET", "/progs/gobs2.go", ""},
-		{"GET", "/progs/image_draw.go", ""},
-		{"GET", "/progs/image_package1.go", ""},
-		{"GET", "/progs/image_package1.out", ""},
-		{"GET", "/progs/image_package2.go", ""},
-		{"GET", "/progs/image_package2.out", ""},
-		{"GET", "/progs/image_package3.go", ""},
-		{"GET", "/progs/image_package3.out", ""},
-		{"GET", "/progs/image_package4.go", ""},
-		{"GET", "/progs/image_package4.out", ""},
-		{"GET", "/progs/image_package5.go", ""},
-		{"GET", "/progs/image_package5.out", ""},
-		{"GET", "/progs/image_package6.go", ""},
-		{"GET", "/progs/image_package6.out", ""},
-		{"GET", "/progs/interface.go", ""},
-		{"GET", "/progs/interface2.go", ""},
-		{"GET", "/progs/interface2.out", ""},
-		{"GET", "/progs/json1.go", ""},
-		{"GET", "/progs/json2.go", ""},
-		{"GET", "/progs/json2.out", ""},
-		{"GET", "/progs/json3.go", ""},
-		{"GET", "/progs/json4.go", ""},
-		{"GET", "/progs/json5.go", ""},
-		{"GET", "/progs/run", ""},
-		{"GET", "/progs/slices.go", ""},
-		{"GET", "/progs/timeout1.go", ""},
-		{"GET", "/progs/timeout2.go", ""},
-		{"GET", "/progs/update.bash", ""},
+		{Method: "GET", Path: "/"},
+		{Method: "GET", Path: "/cmd.html"},
+		{Method: "GET", Path: "/code.html"},
+		{Method: "GET", Path: "/contrib.html"},
+		{Method: "GET", Path: "/contribute.html"},
+		{Method: "GET", Path: "/debugging_with_gdb.html"},
+		{Method: "GET", Path: "/docs.html"},
+		{Method: "GET", Path: "/effective_go.html"},
+		{Method: "GET", Path: "/files.log"},
+		{Method: "GET", Path: "/gccgo_contribute.html"},
+		{Method: "GET", Path: "/gccgo_install.html"},
+		{Method: "GET", Path: "/go-logo-black.png"},
+		{Method: "GET", Path: "/go-logo-blue.png"},
+		{Method: "GET", Path: "/go-logo-white.png"},
+		{Method: "GET", Path: "/go1.1.html"},
+		{Method: "GET", Path: "/go1.2.html"},
+		{Method: "GET", Path: "/go1.html"},
+		{Method: "GET", Path: "/go1compat.html"},
+		{Method: "GET", Path: "/go_faq.html"},
+		{Method: "GET", Path: "/go_mem.html"},
+		{Method: "GET", Path: "/go_spec.html"},
+		{Method: "GET", Path: "/help.html"},
+		{Method: "GET", Path: "/ie.css"},
+		{Method: "GET", Path: "/install-source.html"},
+		{Method: "GET", Path: "/install.html"},
+		{Method: "GET", Path: "/logo-153x55.png"},
+		{Method: "GET", Path: "/Makefile"},
+		{Method: "GET", Path: "/root.html"},
+		{Method: "GET", Path: "/share.png"},
+		{Method: "GET", Path: "/sieve.gif"},
+		{Method: "GET", Path: "/tos.html"},
+		{Method: "GET", Path: "/articles/"},
+		{Method: "GET", Path: "/articles/go_command.html"},
+		{Method: "GET", Path: "/articles/index.html"},
+		{Method: "GET", Path: "/articles/wiki/"},
+		{Method: "GET", Path: "/articles/wiki/edit.html"},
+		{Method: "GET", Path: "/articles/wiki/final-noclosure.go"},
+		{Method: "GET", Path: "/articles/wiki/final-noerror.go"},
+		{Method: "GET", Path: "/articles/wiki/final-parsetemplate.go"},
+		{Method: "GET", Path: "/articles/wiki/final-template.go"},
+		{Method: "GET", Path: "/articles/wiki/final.go"},
+		{Method: "GET", Path: "/articles/wiki/get.go"},
+		{Method: "GET", Path: "/articles/wiki/http-sample.go"},
+		{Method: "GET", Path: "/articles/wiki/index.html"},
+		{Method: "GET", Path: "/articles/wiki/Makefile"},
+		{Method: "GET", Path: "/articles/wiki/notemplate.go"},
+		{Method: "GET", Path: "/articles/wiki/part1-noerror.go"},
+		{Method: "GET", Path: "/articles/wiki/part1.go"},
+		{Method: "GET", Path: "/articles/wiki/part2.go"},
+		{Method: "GET", Path: "/articles/wiki/part3-errorhandling.go"},
+		{Method: "GET", Path: "/articles/wiki/part3.go"},
+		{Method: "GET", Path: "/articles/wiki/test.bash"},
+		{Method: "GET", Path: "/articles/wiki/test_edit.good"},
+		{Method: "GET", Path: "/articles/wiki/test_Test.txt.good"},
+		{Method: "GET", Path: "/articles/wiki/test_view.good"},
+		{Method: "GET", Path: "/articles/wiki/view.html"},
+		{Method: "GET", Path: "/codewalk/"},
+		{Method: "GET", Path: "/codewalk/codewalk.css"},
+		{Method: "GET", Path: "/codewalk/codewalk.js"},
+		{Method: "GET", Path: "/codewalk/codewalk.xml"},
+		{Method: "GET", Path: "/codewalk/functions.xml"},
+		{Method: "GET", Path: "/codewalk/markov.go"},
+		{Method: "GET", Path: "/codewalk/markov.xml"},
+		{Method: "GET", Path: "/codewalk/pig.go"},
+		{Method: "GET", Path: "/codewalk/popout.png"},
+		{Method: "GET", Path: "/codewalk/run"},
+		{Method: "GET", Path: "/codewalk/sharemem.xml"},
+		{Method: "GET", Path: "/codewalk/urlpoll.go"},
+		{Method: "GET", Path: "/devel/"},
+		{Method: "GET", Path: "/devel/release.html"},
+		{Method: "GET", Path: "/devel/weekly.html"},
+		{Method: "GET", Path: "/gopher/"},
+		{Method: "GET", Path: "/gopher/appenginegopher.jpg"},
+		{Method: "GET", Path: "/gopher/appenginegophercolor.jpg"},
+		{Method: "GET", Path: "/gopher/appenginelogo.gif"},
+		{Method: "GET", Path: "/gopher/bumper.png"},
+		{Method: "GET", Path: "/gopher/bumper192x108.png"},
+		{Method: "GET", Path: "/gopher/bumper320x180.png"},
+		{Method: "GET", Path: "/gopher/bumper480x270.png"},
+		{Method: "GET", Path: "/gopher/bumper640x360.png"},
+		{Method: "GET", Path: "/gopher/doc.png"},
+		{Method: "GET", Path: "/gopher/frontpage.png"},
+		{Method: "GET", Path: "/gopher/gopherbw.png"},
+		{Method: "GET", Path: "/gopher/gophercolor.png"},
+		{Method: "GET", Path: "/gopher/gophercolor16x16.png"},
+		{Method: "GET", Path: "/gopher/help.png"},
+		{Method: "GET", Path: "/gopher/pkg.png"},
+		{Method: "GET", Path: "/gopher/project.png"},
+		{Method: "GET", Path: "/gopher/ref.png"},
+		{Method: "GET", Path: "/gopher/run.png"},
+		{Method: "GET", Path: "/gopher/talks.png"},
+		{Method: "GET", Path: "/gopher/pencil/"},
+		{Method: "GET", Path: "/gopher/pencil/gopherhat.jpg"},
+		{Method: "GET", Path: "/gopher/pencil/gopherhelmet.jpg"},
+		{Method: "GET", Path: "/gopher/pencil/gophermega.jpg"},
+		{Method: "GET", Path: "/gopher/pencil/gopherrunning.jpg"},
+		{Method: "GET", Path: "/gopher/pencil/gopherswim.jpg"},
+		{Method: "GET", Path: "/gopher/pencil/gopherswrench.jpg"},
+		{Method: "GET", Path: "/play/"},
+		{Method: "GET", Path: "/play/fib.go"},
+		{Method: "GET", Path: "/play/hello.go"},
+		{Method: "GET", Path: "/play/life.go"},
+		{Method: "GET", Path: "/play/peano.go"},
+		{Method: "GET", Path: "/play/pi.go"},
+		{Method: "GET", Path: "/play/sieve.go"},
+		{Method: "GET", Path: "/play/solitaire.go"},
+		{Method: "GET", Path: "/play/tree.go"},
+		{Method: "GET", Path: "/progs/"},
+		{Method: "GET", Path: "/progs/cgo1.go"},
+		{Method: "GET", Path: "/progs/cgo2.go"},
+		{Method: "GET", Path: "/progs/cgo3.go"},
+		{Method: "GET", Path: "/progs/cgo4.go"},
+		{Method: "GET", Path: "/progs/defer.go"},
+		{Method: "GET", Path: "/progs/defer.out"},
+		{Method: "GET", Path: "/progs/defer2.go"},
+		{Method: "GET", Path: "/progs/defer2.out"},
+		{Method: "GET", Path: "/progs/eff_bytesize.go"},
+		{Method: "GET", Path: "/progs/eff_bytesize.out"},
+		{Method: "GET", Path: "/progs/eff_qr.go"},
+		{Method: "GET", Path: "/progs/eff_sequence.go"},
+		{Method: "GET", Path: "/progs/eff_sequence.out"},
+		{Method: "GET", Path: "/progs/eff_unused1.go"},
+		{Method: "GET", Path: "/progs/eff_unused2.go"},
+		{Method: "GET", Path: "/progs/error.go"},
+		{Method: "GET", Path: "/progs/error2.go"},
+		{Method: "GET", Path: "/progs/error3.go"},
+		{Method: "GET", Path: "/progs/error4.go"},
+		{Method: "GET", Path: "/progs/go1.go"},
+		{Method: "GET", Path: "/progs/gobs1.go"},
+		{Method: "GET", Path: "/progs/gobs2.go"},
+		{Method: "GET", Path: "/progs/image_draw.go"},
+		{Method: "GET", Path: "/progs/image_package1.go"},
+		{Method: "GET", Path: "/progs/image_package1.out"},
+		{Method: "GET", Path: "/progs/image_package2.go"},
+		{Method: "GET", Path: "/progs/image_package2.out"},
+		{Method: "GET", Path: "/progs/image_package3.go"},
+		{Method: "GET", Path: "/progs/image_package3.out"},
+		{Method: "GET", Path: "/progs/image_package4.go"},
+		{Method: "GET", Path: "/progs/image_package4.out"},
+		{Method: "GET", Path: "/progs/image_package5.go"},
+		{Method: "GET", Path: "/progs/image_package5.out"},
+		{Method: "GET", Path: "/progs/image_package6.go"},
+		{Method: "GET", Path: "/progs/image_package6.out"},
+		{Method: "GET", Path: "/progs/interface.go"},
+		{Method: "GET", Path: "/progs/interface2.go"},
+		{Method: "GET", Path: "/progs/interface2.out"},
+		{Method: "GET", Path: "/progs/json1.go"},
+		{Method: "GET", Path: "/progs/json2.go"},
+		{Method: "GET", Path: "/progs/json2.out"},
+		{Method: "GET", Path: "/progs/json3.go"},
+		{Method: "GET", Path: "/progs/json4.go"},
+		{Method: "GET", Path: "/progs/json5.go"},
+		{Method: "GET", Path: "/progs/run"},
+		{Method: "GET", Path: "/progs/slices.go"},
+		{Method: "GET", Path: "/progs/timeout1.go"},
+		{Method: "GET", Path: "/progs/timeout2.go"},
+		{Method: "GET", Path: "/progs/update.bash"},
 	}
 
 	gitHubAPI = []*Route{
 		// OAuth Authorizations
-		{"GET", "/authorizations", ""},
-		{"GET", "/authorizations/:id", ""},
-		{"POST", "/authorizations", ""},
-		//{"PUT", "/authorizations/clients/:client_id", ""},
-		//{"PATCH", "/authorizations/:id", ""},
-		{"DELETE", "/authorizations/:id", ""},
-		{"GET", "/applications/:client_id/tokens/:access_token", ""},
-		{"DELETE", "/applications/:client_id/tokens", ""},
-		{"DELETE", "/applications/:client_id/tokens/:access_token", ""},
+		{Method: "GET", Path: "/authorizations"},
+		{Method: "GET", Path: "/authorizations/:id"},
+		{Method: "POST", Path: "/authorizations"},
+		//{Method: "PUT", Path: "/authorizations/clients/:client_id"},
+		//{Method: "PATCH", Path: "/authorizations/:id"},
+		{Method: "DELETE", Path: "/authorizations/:id"},
+		{Method: "GET", Path: "/applications/:client_id/tokens/:access_token"},
+		{Method: "DELETE", Path: "/applications/:client_id/tokens"},
+		{Method: "DELETE", Path: "/applications/:client_id/tokens/:access_token"},
 
 		// Activity
-		{"GET", "/events", ""},
-		{"GET", "/repos/:owner/:repo/events", ""},
-		{"GET", "/networks/:owner/:repo/events", ""},
-		{"GET", "/orgs/:org/events", ""},
-		{"GET", "/users/:user/received_events", ""},
-		{"GET", "/users/:user/received_events/public", ""},
-		{"GET", "/users/:user/events", ""},
-		{"GET", "/users/:user/events/public", ""},
-		{"GET", "/users/:user/events/orgs/:org", ""},
-		{"GET", "/feeds", ""},
-		{"GET", "/notifications", ""},
-		{"GET", "/repos/:owner/:repo/notifications", ""},
-		{"PUT", "/notifications", ""},
-		{"PUT", "/repos/:owner/:repo/notifications", ""},
-		{"GET", "/notifications/threads/:id", ""},
-		//{"PATCH", "/notifications/threads/:id", ""},
-		{"GET", "/notifications/threads/:id/subscription", ""},
-		{"PUT", "/notifications/threads/:id/subscription", ""},
-		{"DELETE", "/notifications/threads/:id/subscription", ""},
-		{"GET", "/repos/:owner/:repo/stargazers", ""},
-		{"GET", "/users/:user/starred", ""},
-		{"GET", "/user/starred", ""},
-		{"GET", "/user/starred/:owner/:repo", ""},
-		{"PUT", "/user/starred/:owner/:repo", ""},
-		{"DELETE", "/user/starred/:owner/:repo", ""},
-		{"GET", "/repos/:owner/:repo/subscribers", ""},
-		{"GET", "/users/:user/subscriptions", ""},
-		{"GET", "/user/subscriptions", ""},
-		{"GET", "/repos/:owner/:repo/subscription", ""},
-		{"PUT", "/repos/:owner/:repo/subscription", ""},
-		{"DELETE", "/repos/:owner/:repo/subscription", ""},
-		{"GET", "/user/subscriptions/:owner/:repo", ""},
-		{"PUT", "/user/subscriptions/:owner/:repo", ""},
-		{"DELETE", "/user/subscriptions/:owner/:repo", ""},
+		{Method: "GET", Path: "/events"},
+		{Method: "GET", Path: "/repos/:owner/:repo/events"},
+		{Method: "GET", Path: "/networks/:owner/:repo/events"},
+		{Method: "GET", Path: "/orgs/:org/events"},
+		{Method: "GET", Path: "/users/:user/received_events"},
+		{Method: "GET", Path: "/users/:user/received_events/public"},
+		{Method: "GET", Path: "/users/:user/events"},
+		{Method: "GET", Path: "/users/:user/events/public"},
+		{Method: "GET", Path: "/users/:user/events/orgs/:org"},
+		{Method: "GET", Path: "/feeds"},
+		{Method: "GET", Path: "/notifications"},
+		{Method: "GET", Path: "/repos/:owner/:repo/notifications"},
+		{Method: "PUT", Path: "/notifications"},
+		{Method: "PUT", Path: "/repos/:owner/:repo/notifications"},
+		{Method: "GET", Path: "/notifications/threads/:id"},
+		//{Method: "PATCH", Path: "/notifications/threads/:id"},
+		{Method: "GET", Path: "/notifications/threads/:id/subscription"},
+		{Method: "PUT", Path: "/notifications/threads/:id/subscription"},
+		{Method: "DELETE", Path: "/notifications/threads/:id/subscription"},
+		{Method: "GET", Path: "/repos/:owner/:repo/stargazers"},
+		{Method: "GET", Path: "/users/:user/starred"},
+		{Method: "GET", Path: "/user/starred"},
+		{Method: "GET", Path: "/user/starred/:owner/:repo"},
+		{Method: "PUT", Path: "/user/starred/:owner/:repo"},
+		{Method: "DELETE", Path: "/user/starred/:owner/:repo"},
+		{Method: "GET", Path: "/repos/:owner/:repo/subscribers"},
+		{Method: "GET", Path: "/users/:user/subscriptions"},
+		{Method: "GET", Path: "/user/subscriptions"},
+		{Method: "GET", Path: "/repos/:owner/:repo/subscription"},
+		{Method: "PUT", Path: "/repos/:owner/:repo/subscription"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo/subscription"},
+		{Method: "GET", Path: "/user/subscriptions/:owner/:repo"},
+		{Method: "PUT", Path: "/user/subscriptions/:owner/:repo"},
+		{Method: "DELETE", Path: "/user/subscriptions/:owner/:repo"},
 
 		// Gists
-		{"GET", "/users/:user/gists", ""},
-		{"GET", "/gists", ""},
-		//{"GET", "/gists/public", ""},
-		//{"GET", "/gists/starred", ""},
-		{"GET", "/gists/:id", ""},
-		{"POST", "/gists", ""},
-		//{"PATCH", "/gists/:id", ""},
-		{"PUT", "/gists/:id/star", ""},
-		{"DELETE", "/gists/:id/star", ""},
-		{"GET", "/gists/:id/star", ""},
-		{"POST", "/gists/:id/forks", ""},
-		{"DELETE", "/gists/:id", ""},
+		{Method: "GET", Path: "/users/:user/gists"},
+		{Method: "GET", Path: "/gists"},
+		//{Method: "GET", Path: "/gists/public"},
+		//{Method: "GET", Path: "/gists/starred"},
+		{Method: "GET", Path: "/gists/:id"},
+		{Method: "POST", Path: "/gists"},
+		//{Method: "PATCH", Path: "/gists/:id"},
+		{Method: "PUT", Path: "/gists/:id/star"},
+		{Method: "DELETE", Path: "/gists/:id/star"},
+		{Method: "GET", Path: "/gists/:id/star"},
+		{Method: "POST", Path: "/gists/:id/forks"},
+		{Method: "DELETE", Path: "/gists/:id"},
 
 		// Git Data
-		{"GET", "/repos/:owner/:repo/git/blobs/:sha", ""},
-		{"POST", "/repos/:owner/:repo/git/blobs", ""},
-		{"GET", "/repos/:owner/:repo/git/commits/:sha", ""},
-		{"POST", "/repos/:owner/:repo/git/commits", ""},
-		//{"GET", "/repos/:owner/:repo/git/refs/*ref", ""},
-		{"GET", "/repos/:owner/:repo/git/refs", ""},
-		{"POST", "/repos/:owner/:repo/git/refs", ""},
-		//{"PATCH", "/repos/:owner/:repo/git/refs/*ref", ""},
-		//{"DELETE", "/repos/:owner/:repo/git/refs/*ref", ""},
-		{"GET", "/repos/:owner/:repo/git/tags/:sha", ""},
-		{"POST", "/repos/:owner/:repo/git/tags", ""},
-		{"GET", "/repos/:owner/:repo/git/trees/:sha", ""},
-		{"POST", "/repos/:owner/:repo/git/trees", ""},
+		{Method: "GET", Path: "/repos/:owner/:repo/git/blobs/:sha"},
+		{Method: "POST", Path: "/repos/:owner/:repo/git/blobs"},
+		{Method: "GET", Path: "/repos/:owner/:repo/git/commits/:sha"},
+		{Method: "POST", Path: "/repos/:owner/:repo/git/commits"},
+		//{Method: "GET", Path: "/repos/:owner/:repo/git/refs/*ref"},
+		{Method: "GET", Path: "/repos/:owner/:repo/git/refs"},
+		{Method: "POST", Path: "/repos/:owner/:repo/git/refs"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo/git/refs/*ref"},
+		//{Method: "DELETE", Path: "/repos/:owner/:repo/git/refs/*ref"},
+		{Method: "GET", Path: "/repos/:owner/:repo/git/tags/:sha"},
+		{Method: "POST", Path: "/repos/:owner/:repo/git/tags"},
+		{Method: "GET", Path: "/repos/:owner/:repo/git/trees/:sha"},
+		{Method: "POST", Path: "/repos/:owner/:repo/git/trees"},
 
 		// Issues
-		{"GET", "/issues", ""},
-		{"GET", "/user/issues", ""},
-		{"GET", "/orgs/:org/issues", ""},
-		{"GET", "/repos/:owner/:repo/issues", ""},
-		{"GET", "/repos/:owner/:repo/issues/:number", ""},
-		{"POST", "/repos/:owner/:repo/issues", ""},
-		//{"PATCH", "/repos/:owner/:repo/issues/:number", ""},
-		{"GET", "/repos/:owner/:repo/assignees", ""},
-		{"GET", "/repos/:owner/:repo/assignees/:assignee", ""},
-		{"GET", "/repos/:owner/:repo/issues/:number/comments", ""},
-		//{"GET", "/repos/:owner/:repo/issues/comments", ""},
-		//{"GET", "/repos/:owner/:repo/issues/comments/:id", ""},
-		{"POST", "/repos/:owner/:repo/issues/:number/comments", ""},
-		//{"PATCH", "/repos/:owner/:repo/issues/comments/:id", ""},
-		//{"DELETE", "/repos/:owner/:repo/issues/comments/:id", ""},
-		{"GET", "/repos/:owner/:repo/issues/:number/events", ""},
-		//{"GET", "/repos/:owner/:repo/issues/events", ""},
-		//{"GET", "/repos/:owner/:repo/issues/events/:id", ""},
-		{"GET", "/repos/:owner/:repo/labels", ""},
-		{"GET", "/repos/:owner/:repo/labels/:name", ""},
-		{"POST", "/repos/:owner/:repo/labels", ""},
-		//{"PATCH", "/repos/:owner/:repo/labels/:name", ""},
-		{"DELETE", "/repos/:owner/:repo/labels/:name", ""},
-		{"GET", "/repos/:owner/:repo/issues/:number/labels", ""},
-		{"POST", "/repos/:owner/:repo/issues/:number/labels", ""},
-		{"DELETE", "/repos/:owner/:repo/issues/:number/labels/:name", ""},
-		{"PUT", "/repos/:owner/:repo/issues/:number/labels", ""},
-		{"DELETE", "/repos/:owner/:repo/issues/:number/labels", ""},
-		{"GET", "/repos/:owner/:repo/milestones/:number/labels", ""},
-		{"GET", "/repos/:owner/:repo/milestones", ""},
-		{"GET", "/repos/:owner/:repo/milestones/:number", ""},
-		{"POST", "/repos/:owner/:repo/milestones", ""},
-		//{"PATCH", "/repos/:owner/:repo/milestones/:number", ""},
-		{"DELETE", "/repos/:owner/:repo/milestones/:number", ""},
+		{Method: "GET", Path: "/issues"},
+		{Method: "GET", Path: "/user/issues"},
+		{Method: "GET", Path: "/orgs/:org/issues"},
+		{Method: "GET", Path: "/repos/:owner/:repo/issues"},
+		{Method: "GET", Path: "/repos/:owner/:repo/issues/:number"},
+		{Method: "POST", Path: "/repos/:owner/:repo/issues"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo/issues/:number"},
+		{Method: "GET", Path: "/repos/:owner/:repo/assignees"},
+		{Method: "GET", Path: "/repos/:owner/:repo/assignees/:assignee"},
+		{Method: "GET", Path: "/repos/:owner/:repo/issues/:number/comments"},
+		//{Method: "GET", Path: "/repos/:owner/:repo/issues/comments"},
+		//{Method: "GET", Path: "/repos/:owner/:repo/issues/comments/:id"},
+		{Method: "POST", Path: "/repos/:owner/:repo/issues/:number/comments"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo/issues/comments/:id"},
+		//{Method: "DELETE", Path: "/repos/:owner/:repo/issues/comments/:id"},
+		{Method: "GET", Path: "/repos/:owner/:repo/issues/:number/events"},
+		//{Method: "GET", Path: "/repos/:owner/:repo/issues/events"},
+		//{Method: "GET", Path: "/repos/:owner/:repo/issues/events/:id"},
+		{Method: "GET", Path: "/repos/:owner/:repo/labels"},
+		{Method: "GET", Path: "/repos/:owner/:repo/labels/:name"},
+		{Method: "POST", Path: "/repos/:owner/:repo/labels"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo/labels/:name"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo/labels/:name"},
+		{Method: "GET", Path: "/repos/:owner/:repo/issues/:number/labels"},
+		{Method: "POST", Path: "/repos/:owner/:repo/issues/:number/labels"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo/issues/:number/labels/:name"},
+		{Method: "PUT", Path: "/repos/:owner/:repo/issues/:number/labels"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo/issues/:number/labels"},
+		{Method: "GET", Path: "/repos/:owner/:repo/milestones/:number/labels"},
+		{Method: "GET", Path: "/repos/:owner/:repo/milestones"},
+		{Method: "GET", Path: "/repos/:owner/:repo/milestones/:number"},
+		{Method: "POST", Path: "/repos/:owner/:repo/milestones"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo/milestones/:number"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo/milestones/:number"},
 
 		// Miscellaneous
-		{"GET", "/emojis", ""},
-		{"GET", "/gitignore/templates", ""},
-		{"GET", "/gitignore/templates/:name", ""},
-		{"POST", "/markdown", ""},
-		{"POST", "/markdown/raw", ""},
-		{"GET", "/meta", ""},
-		{"GET", "/rate_limit", ""},
+		{Method: "GET", Path: "/emojis"},
+		{Method: "GET", Path: "/gitignore/templates"},
+		{Method: "GET", Path: "/gitignore/templates/:name"},
+		{Method: "POST", Path: "/markdown"},
+		{Method: "POST", Path: "/markdown/raw"},
+		{Method: "GET", Path: "/meta"},
+		{Method: "GET", Path: "/rate_limit"},
 
 		// Organizations
-		{"GET", "/users/:user/orgs", ""},
-		{"GET", "/user/orgs", ""},
-		{"GET", "/orgs/:org", ""},
-		//{"PATCH", "/orgs/:org", ""},
-		{"GET", "/orgs/:org/members", ""},
-		{"GET", "/orgs/:org/members/:user", ""},
-		{"DELETE", "/orgs/:org/members/:user", ""},
-		{"GET", "/orgs/:org/public_members", ""},
-		{"GET", "/orgs/:org/public_members/:user", ""},
-		{"PUT", "/orgs/:org/public_members/:user", ""},
-		{"DELETE", "/orgs/:org/public_members/:user", ""},
-		{"GET", "/orgs/:org/teams", ""},
-		{"GET", "/teams/:id", ""},
-		{"POST", "/orgs/:org/teams", ""},
-		//{"PATCH", "/teams/:id", ""},
-		{"DELETE", "/teams/:id", ""},
-		{"GET", "/teams/:id/members", ""},
-		{"GET", "/teams/:id/members/:user", ""},
-		{"PUT", "/teams/:id/members/:user", ""},
-		{"DELETE", "/teams/:id/members/:user", ""},
-		{"GET", "/teams/:id/repos", ""},
-		{"GET", "/teams/:id/repos/:owner/:repo", ""},
-		{"PUT", "/teams/:id/repos/:owner/:repo", ""},
-		{"DELETE", "/teams/:id/repos/:owner/:repo", ""},
-		{"GET", "/user/teams", ""},
+		{Method: "GET", Path: "/users/:user/orgs"},
+		{Method: "GET", Path: "/user/orgs"},
+		{Method: "GET", Path: "/orgs/:org"},
+		//{Method: "PATCH", Path: "/orgs/:org"},
+		{Method: "GET", Path: "/orgs/:org/members"},
+		{Method: "GET", Path: "/orgs/:org/members/:user"},
+		{Method: "DELETE", Path: "/orgs/:org/members/:user"},
+		{Method: "GET", Path: "/orgs/:org/public_members"},
+		{Method: "GET", Path: "/orgs/:org/public_members/:user"},
+		{Method: "PUT", Path: "/orgs/:org/public_members/:user"},
+		{Method: "DELETE", Path: "/orgs/:org/public_members/:user"},
+		{Method: "GET", Path: "/orgs/:org/teams"},
+		{Method: "GET", Path: "/teams/:id"},
+		{Method: "POST", Path: "/orgs/:org/teams"},
+		//{Method: "PATCH", Path: "/teams/:id"},
+		{Method: "DELETE", Path: "/teams/:id"},
+		{Method: "GET", Path: "/teams/:id/members"},
+		{Method: "GET", Path: "/teams/:id/members/:user"},
+		{Method: "PUT", Path: "/teams/:id/members/:user"},
+		{Method: "DELETE", Path: "/teams/:id/members/:user"},
+		{Method: "GET", Path: "/teams/:id/repos"},
+		{Method: "GET", Path: "/teams/:id/repos/:owner/:repo"},
+		{Method: "PUT", Path: "/teams/:id/repos/:owner/:repo"},
+		{Method: "DELETE", Path: "/teams/:id/repos/:owner/:repo"},
+		{Method: "GET", Path: "/user/teams"},
 
 		// Pull Requests
-		{"GET", "/repos/:owner/:repo/pulls", ""},
-		{"GET", "/repos/:owner/:repo/pulls/:number", ""},
-		{"POST", "/repos/:owner/:repo/pulls", ""},
-		//{"PATCH", "/repos/:owner/:repo/pulls/:number", ""},
-		{"GET", "/repos/:owner/:repo/pulls/:number/commits", ""},
-		{"GET", "/repos/:owner/:repo/pulls/:number/files", ""},
-		{"GET", "/repos/:owner/:repo/pulls/:number/merge", ""},
-		{"PUT", "/repos/:owner/:repo/pulls/:number/merge", ""},
-		{"GET", "/repos/:owner/:repo/pulls/:number/comments", ""},
-		//{"GET", "/repos/:owner/:repo/pulls/comments", ""},
-		//{"GET", "/repos/:owner/:repo/pulls/comments/:number", ""},
-		{"PUT", "/repos/:owner/:repo/pulls/:number/comments", ""},
-		//{"PATCH", "/repos/:owner/:repo/pulls/comments/:number", ""},
-		//{"DELETE", "/repos/:owner/:repo/pulls/comments/:number", ""},
+		{Method: "GET", Path: "/repos/:owner/:repo/pulls"},
+		{Method: "GET", Path: "/repos/:owner/:repo/pulls/:number"},
+		{Method: "POST", Path: "/repos/:owner/:repo/pulls"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo/pulls/:number"},
+		{Method: "GET", Path: "/repos/:owner/:repo/pulls/:number/commits"},
+		{Method: "GET", Path: "/repos/:owner/:repo/pulls/:number/files"},
+		{Method: "GET", Path: "/repos/:owner/:repo/pulls/:number/merge"},
+		{Method: "PUT", Path: "/repos/:owner/:repo/pulls/:number/merge"},
+		{Method: "GET", Path: "/repos/:owner/:repo/pulls/:number/comments"},
+		//{Method: "GET", Path: "/repos/:owner/:repo/pulls/comments"},
+		//{Method: "GET", Path: "/repos/:owner/:repo/pulls/comments/:number"},
+		{Method: "PUT", Path: "/repos/:owner/:repo/pulls/:number/comments"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo/pulls/comments/:number"},
+		//{Method: "DELETE", Path: "/repos/:owner/:repo/pulls/comments/:number"},
 
 		// Repositories
-		{"GET", "/user/repos", ""},
-		{"GET", "/users/:user/repos", ""},
-		{"GET", "/orgs/:org/repos", ""},
-		{"GET", "/repositories", ""},
-		{"POST", "/user/repos", ""},
-		{"POST", "/orgs/:org/repos", ""},
-		{"GET", "/repos/:owner/:repo", ""},
-		//{"PATCH", "/repos/:owner/:repo", ""},
-		{"GET", "/repos/:owner/:repo/contributors", ""},
-		{"GET", "/repos/:owner/:repo/languages", ""},
-		{"GET", "/repos/:owner/:repo/teams", ""},
-		{"GET", "/repos/:owner/:repo/tags", ""},
-		{"GET", "/repos/:owner/:repo/branches", ""},
-		{"GET", "/repos/:owner/:repo/branches/:branch", ""},
-		{"DELETE", "/repos/:owner/:repo", ""},
-		{"GET", "/repos/:owner/:repo/collaborators", ""},
-		{"GET", "/repos/:owner/:repo/collaborators/:user", ""},
-		{"PUT", "/repos/:owner/:repo/collaborators/:user", ""},
-		{"DELETE", "/repos/:owner/:repo/collaborators/:user", ""},
-		{"GET", "/repos/:owner/:repo/comments", ""},
-		{"GET", "/repos/:owner/:repo/commits/:sha/comments", ""},
-		{"POST", "/repos/:owner/:repo/commits/:sha/comments", ""},
-		{"GET", "/repos/:owner/:repo/comments/:id", ""},
-		//{"PATCH", "/repos/:owner/:repo/comments/:id", ""},
-		{"DELETE", "/repos/:owner/:repo/comments/:id", ""},
-		{"GET", "/repos/:owner/:repo/commits", ""},
-		{"GET", "/repos/:owner/:repo/commits/:sha", ""},
-		{"GET", "/repos/:owner/:repo/readme", ""},
-		//{"GET", "/repos/:owner/:repo/contents/*path", ""},
-		//{"PUT", "/repos/:owner/:repo/contents/*path", ""},
-		//{"DELETE", "/repos/:owner/:repo/contents/*path", ""},
-		//{"GET", "/repos/:owner/:repo/:archive_format/:ref", ""},
-		{"GET", "/repos/:owner/:repo/keys", ""},
-		{"GET", "/repos/:owner/:repo/keys/:id", ""},
-		{"POST", "/repos/:owner/:repo/keys", ""},
-		//{"PATCH", "/repos/:owner/:repo/keys/:id", ""},
-		{"DELETE", "/repos/:owner/:repo/keys/:id", ""},
-		{"GET", "/repos/:owner/:repo/downloads", ""},
-		{"GET", "/repos/:owner/:repo/downloads/:id", ""},
-		{"DELETE", "/repos/:owner/:repo/downloads/:id", ""},
-		{"GET", "/repos/:owner/:repo/forks", ""},
-		{"POST", "/repos/:owner/:repo/forks", ""},
-		{"GET", "/repos/:owner/:repo/hooks", ""},
-		{"GET", "/repos/:owner/:repo/hooks/:id", ""},
-		{"POST", "/repos/:owner/:repo/hooks", ""},
-		//{"PATCH", "/repos/:owner/:repo/hooks/:id", ""},
-		{"POST", "/repos/:owner/:repo/hooks/:id/tests", ""},
-		{"DELETE", "/repos/:owner/:repo/hooks/:id", ""},
-		{"POST", "/repos/:owner/:repo/merges", ""},
-		{"GET", "/repos/:owner/:repo/releases", ""},
-		{"GET", "/repos/:owner/:repo/releases/:id", ""},
-		{"POST", "/repos/:owner/:repo/releases", ""},
-		//{"PATCH", "/repos/:owner/:repo/releases/:id", ""},
-		{"DELETE", "/repos/:owner/:repo/releases/:id", ""},
-		{"GET", "/repos/:owner/:repo/releases/:id/assets", ""},
-		{"GET", "/repos/:owner/:repo/stats/contributors", ""},
-		{"GET", "/repos/:owner/:repo/stats/commit_activity", ""},
-		{"GET", "/repos/:owner/:repo/stats/code_frequency", ""},
-		{"GET", "/repos/:owner/:repo/stats/participation", ""},
-		{"GET", "/repos/:owner/:repo/stats/punch_card", ""},
-		{"GET", "/repos/:owner/:repo/statuses/:ref", ""},
-		{"POST", "/repos/:owner/:repo/statuses/:ref", ""},
+		{Method: "GET", Path: "/user/repos"},
+		{Method: "GET", Path: "/users/:user/repos"},
+		{Method: "GET", Path: "/orgs/:org/repos"},
+		{Method: "GET", Path: "/repositories"},
+		{Method: "POST", Path: "/user/repos"},
+		{Method: "POST", Path: "/orgs/:org/repos"},
+		{Method: "GET", Path: "/repos/:owner/:repo"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo"},
+		{Method: "GET", Path: "/repos/:owner/:repo/contributors"},
+		{Method: "GET", Path: "/repos/:owner/:repo/languages"},
+		{Method: "GET", Path: "/repos/:owner/:repo/teams"},
+		{Method: "GET", Path: "/repos/:owner/:repo/tags"},
+		{Method: "GET", Path: "/repos/:owner/:repo/branches"},
+		{Method: "GET", Path: "/repos/:owner/:repo/branches/:branch"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo"},
+		{Method: "GET", Path: "/repos/:owner/:repo/collaborators"},
+		{Method: "GET", Path: "/repos/:owner/:repo/collaborators/:user"},
+		{Method: "PUT", Path: "/repos/:owner/:repo/collaborators/:user"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo/collaborators/:user"},
+		{Method: "GET", Path: "/repos/:owner/:repo/comments"},
+		{Method: "GET", Path: "/repos/:owner/:repo/commits/:sha/comments"},
+		{Method: "POST", Path: "/repos/:owner/:repo/commits/:sha/comments"},
+		{Method: "GET", Path: "/repos/:owner/:repo/comments/:id"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo/comments/:id"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo/comments/:id"},
+		{Method: "GET", Path: "/repos/:owner/:repo/commits"},
+		{Method: "GET", Path: "/repos/:owner/:repo/commits/:sha"},
+		{Method: "GET", Path: "/repos/:owner/:repo/readme"},
+		//{Method: "GET", Path: "/repos/:owner/:repo/contents/*path"},
+		//{Method: "PUT", Path: "/repos/:owner/:repo/contents/*path"},
+		//{Method: "DELETE", Path: "/repos/:owner/:repo/contents/*path"},
+		//{Method: "GET", Path: "/repos/:owner/:repo/:archive_format/:ref"},
+		{Method: "GET", Path: "/repos/:owner/:repo/keys"},
+		{Method: "GET", Path: "/repos/:owner/:repo/keys/:id"},
+		{Method: "POST", Path: "/repos/:owner/:repo/keys"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo/keys/:id"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo/keys/:id"},
+		{Method: "GET", Path: "/repos/:owner/:repo/downloads"},
+		{Method: "GET", Path: "/repos/:owner/:repo/downloads/:id"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo/downloads/:id"},
+		{Method: "GET", Path: "/repos/:owner/:repo/forks"},
+		{Method: "POST", Path: "/repos/:owner/:repo/forks"},
+		{Method: "GET", Path: "/repos/:owner/:repo/hooks"},
+		{Method: "GET", Path: "/repos/:owner/:repo/hooks/:id"},
+		{Method: "POST", Path: "/repos/:owner/:repo/hooks"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo/hooks/:id"},
+		{Method: "POST", Path: "/repos/:owner/:repo/hooks/:id/tests"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo/hooks/:id"},
+		{Method: "POST", Path: "/repos/:owner/:repo/merges"},
+		{Method: "GET", Path: "/repos/:owner/:repo/releases"},
+		{Method: "GET", Path: "/repos/:owner/:repo/releases/:id"},
+		{Method: "POST", Path: "/repos/:owner/:repo/releases"},
+		//{Method: "PATCH", Path: "/repos/:owner/:repo/releases/:id"},
+		{Method: "DELETE", Path: "/repos/:owner/:repo/releases/:id"},
+		{Method: "GET", Path: "/repos/:owner/:repo/releases/:id/assets"},
+		{Method: "GET", Path: "/repos/:owner/:repo/stats/contributors"},
+		{Method: "GET", Path: "/repos/:owner/:repo/stats/commit_activity"},
+		{Method: "GET", Path: "/repos/:owner/:repo/stats/code_frequency"},
+		{Method: "GET", Path: "/repos/:owner/:repo/stats/participation"},
+		{Method: "GET", Path: "/repos/:owner/:repo/stats/punch_card"},
+		{Method: "GET", Path: "/repos/:owner/:repo/statuses/:ref"},
+		{Method: "POST", Path: "/repos/:owner/:repo/statuses/:ref"},
 
 		// Search
-		{"GET", "/search/repositories", ""},
-		{"GET", "/search/code", ""},
-		{"GET", "/search/issues", ""},
-		{"GET", "/search/users", ""},
-		{"GET", "/legacy/issues/search/:owner/:repository/:state/:keyword", ""},
-		{"GET", "/legacy/repos/search/:keyword", ""},
-		{"GET", "/legacy/user/search/:keyword", ""},
-		{"GET", "/legacy/user/email/:email", ""},
+		{Method: "GET", Path: "/search/repositories"},
+		{Method: "GET", Path: "/search/code"},
+		{Method: "GET", Path: "/search/issues"},
+		{Method: "GET", Path: "/search/users"},
+		{Method: "GET", Path: "/legacy/issues/search/:owner/:repository/:state/:keyword"},
+		{Method: "GET", Path: "/legacy/repos/search/:keyword"},
+		{Method: "GET", Path: "/legacy/user/search/:keyword"},
+		{Method: "GET", Path: "/legacy/user/email/:email"},
 
 		// Users
-		{"GET", "/users/:user", ""},
-		{"GET", "/user", ""},
-		//{"PATCH", "/user", ""},
-		{"GET", "/users", ""},
-		{"GET", "/user/emails", ""},
-		{"POST", "/user/emails", ""},
-		{"DELETE", "/user/emails", ""},
-		{"GET", "/users/:user/followers", ""},
-		{"GET", "/user/followers", ""},
-		{"GET", "/users/:user/following", ""},
-		{"GET", "/user/following", ""},
-		{"GET", "/user/following/:user", ""},
-		{"GET", "/users/:user/following/:target_user", ""},
-		{"PUT", "/user/following/:user", ""},
-		{"DELETE", "/user/following/:user", ""},
-		{"GET", "/users/:user/keys", ""},
-		{"GET", "/user/keys", ""},
-		{"GET", "/user/keys/:id", ""},
-		{"POST", "/user/keys", ""},
-		//{"PATCH", "/user/keys/:id", ""},
-		{"DELETE", "/user/keys/:id", ""},
+		{Method: "GET", Path: "/users/:user"},
+		{Method: "GET", Path: "/user"},
+		//{Method: "PATCH", Path: "/user"},
+		{Method: "GET", Path: "/users"},
+		{Method: "GET", Path: "/user/emails"},
+		{Method: "POST", Path: "/user/emails"},
+		{Method: "DELETE", Path: "/user/emails"},
+		{Method: "GET", Path: "/users/:user/followers"},
+		{Method: "GET", Path: "/user/followers"},
+		{Method: "GET", Path: "/users/:user/following"},
+		{Method: "GET", Path: "/user/following"},
+		{Method: "GET", Path: "/user/following/:user"},
+		{Method: "GET", Path: "/users/:user/following/:target_user"},
+		{Method: "PUT", Path: "/user/following/:user"},
+		{Method: "DELETE", Path: "/user/following/:user"},
+		{Method: "GET", Path: "/users/:user/keys"},
+		{Method: "GET", Path: "/user/keys"},
+		{Method: "GET", Path: "/user/keys/:id"},
+		{Method: "POST", Path: "/user/keys"},
+		//{Method: "PATCH", Path: "/user/keys/:id"},
+		{Method: "DELETE", Path: "/user/keys/:id"},
 	}
 
 	parseAPI = []*Route{
 		// Objects
-		{"POST", "/1/classes/:className", ""},
-		{"GET", "/1/classes/:className/:objectId", ""},
-		{"PUT", "/1/classes/:className/:objectId", ""},
-		{"GET", "/1/classes/:className", ""},
-		{"DELETE", "/1/classes/:className/:objectId", ""},
+		{Method: "POST", Path: "/1/classes/:className"},
+		{Method: "GET", Path: "/1/classes/:className/:objectId"},
+		{Method: "PUT", Path: "/1/classes/:className/:objectId"},
+		{Method: "GET", Path: "/1/classes/:className"},
+		{Method: "DELETE", Path: "/1/classes/:className/:objectId"},
 
 		// Users
-		{"POST", "/1/users", ""},
-		{"GET", "/1/login", ""},
-		{"GET", "/1/users/:objectId", ""},
-		{"PUT", "/1/users/:objectId", ""},
-		{"GET", "/1/users", ""},
-		{"DELETE", "/1/users/:objectId", ""},
-		{"POST", "/1/requestPasswordReset", ""},
+		{Method: "POST", Path: "/1/users"},
+		{Method: "GET", Path: "/1/login"},
+		{Method: "GET", Path: "/1/users/:objectId"},
+		{Method: "PUT", Path: "/1/users/:objectId"},
+		{Method: "GET", Path: "/1/users"},
+		{Method: "DELETE", Path: "/1/users/:objectId"},
+		{Method: "POST", Path: "/1/requestPasswordReset"},
 
 		// Roles
-		{"POST", "/1/roles", ""},
-		{"GET", "/1/roles/:objectId", ""},
-		{"PUT", "/1/roles/:objectId", ""},
-		{"GET", "/1/roles", ""},
-		{"DELETE", "/1/roles/:objectId", ""},
+		{Method: "POST", Path: "/1/roles"},
+		{Method: "GET", Path: "/1/roles/:objectId"},
+		{Method: "PUT", Path: "/1/roles/:objectId"},
+		{Method: "GET", Path: "/1/roles"},
+		{Method: "DELETE", Path: "/1/roles/:objectId"},
 
 		// Files
-		{"POST", "/1/files/:fileName", ""},
+		{Method: "POST", Path: "/1/files/:fileName"},
 
 		// Analytics
-		{"POST", "/1/events/:eventName", ""},
+		{Method: "POST", Path: "/1/events/:eventName"},
 
 		// Push Notifications
-		{"POST", "/1/push", ""},
+		{Method: "POST", Path: "/1/push"},
 
 		// Installations
-		{"POST", "/1/installations", ""},
-		{"GET", "/1/installations/:objectId", ""},
-		{"PUT", "/1/installations/:objectId", ""},
-		{"GET", "/1/installations", ""},
-		{"DELETE", "/1/installations/:objectId", ""},
+		{Method: "POST", Path: "/1/installations"},
+		{Method: "GET", Path: "/1/installations/:objectId"},
+		{Method: "PUT", Path: "/1/installations/:objectId"},
+		{Method: "GET", Path: "/1/installations"},
+		{Method: "DELETE", Path: "/1/installations/:objectId"},
 
 		// Cloud Functions
-		{"POST", "/1/functions", ""},
+		{Method: "POST", Path: "/1/functions"},
 	}
 
 	googlePlusAPI = []*Route{
 		// People
-		{"GET", "/people/:userId", ""},
-		{"GET", "/people", ""},
-		{"GET", "/activities/:activityId/people/:collection", ""},
-		{"GET", "/people/:userId/people/:collection", ""},
-		{"GET", "/people/:userId/openIdConnect", ""},
+		{Method: "GET", Path: "/people/:userId"},
+		{Method: "GET", Path: "/people"},
+		{Method: "GET", Path: "/activities/:activityId/people/:collection"},
+		{Method: "GET", Path: "/people/:userId/people/:collection"},
+		{Method: "GET", Path: "/people/:userId/openIdConnect"},
 
 		// Activities
-		{"GET", "/people/:userId/activities/:collection", ""},
-		{"GET", "/activities/:activityId", ""},
-		{"GET", "/activities", ""},
+		{Method: "GET", Path: "/people/:userId/activities/:collection"},
+		{Method: "GET", Path: "/activities/:activityId"},
+		{Method: "GET", Path: "/activities"},
 
 		// Comments
-		{"GET", "/activities/:activityId/comments", ""},
-		{"GET", "/comments/:commentId", ""},
+		{Method: "GET", Path: "/activities/:activityId/comments"},
+		{Method: "GET", Path: "/comments/:commentId"},
 
 		// Moments
-		{"POST", "/people/:userId/moments/:collection", ""},
-		{"GET", "/people/:userId/moments/:collection", ""},
-		{"DELETE", "/moments/:id", ""},
+		{Method: "POST", Path: "/people/:userId/moments/:collection"},
+		{Method: "GET", Path: "/people/:userId/moments/:collection"},
+		{Method: "DELETE", Path: "/moments/:id"},
 	}
 )
 
@@ -515,6 +517,39 @@ func TestRouterStatic(t *testing.T) {
 	assert.Equal(t, path, c.Get("path"))
 }
 
+func TestRouterCustomMethod(t *testing.T) {
+	e := New()
+	r := e.router
+	r.add("MKCOL", "/folders/:name", func(c Context) error {
+		c.Set("method", "MKCOL")
+		return nil
+	})
+	r.add("PURGE", "/folders/:name", func(c Context) error {
+		c.Set("method", "PURGE")
+		return nil
+	})
+	c := e.NewContext(nil, httptest.NewRecorder()).(*context)
+
+	r.find("PURGE", "/folders/docs", c)
+	c.handler(c)
+	assert.Equal(t, "PURGE", c.Get("method"))
+	assert.Equal(t, "docs", c.Param("name"))
+
+	r.find(http.MethodGet, "/folders/docs", c)
+	assert.Equal(t, http.StatusMethodNotAllowed, c.handler(c).(*HTTPError).Code)
+	assert.Equal(t, []string{"MKCOL", "PURGE"}, c.AllowedMethods())
+
+	r.find("REPORT", "/folders/docs", c)
+	assert.Equal(t, http.StatusMethodNotAllowed, c.handler(c).(*HTTPError).Code)
+
+	assert.Panics(t, func() {
+		r.add("BAD METHOD", "/", func(c Context) error { return nil })
+	})
+	assert.Panics(t, func() {
+		r.add("", "/", func(c Context) error { return nil })
+	})
+}
+
 func TestRouterParam(t *testing.T) {
 	e := New()
 	r := e.router
@@ -526,6 +561,79 @@ func TestRouterParam(t *testing.T) {
 	assert.Equal(t, "1", c.Param("id"))
 }
 
+func TestRouterParamConstraint(t *testing.T) {
+	e := New()
+	e.RegisterMatcher("even", func(v string) bool {
+		n, err := strconv.Atoi(v)
+		return err == nil && n%2 == 0
+	})
+	r := e.router
+	route := func(name string) HandlerFunc {
+		return func(c Context) error {
+			c.Set("route", name)
+			return nil
+		}
+	}
+	r.add(http.MethodGet, "/users/:id<int>", route("id"))
+	r.add(http.MethodGet, "/users/:name", route("name"))
+	r.add(http.MethodGet, "/users/:id<int>/files/:file<[a-z]+\\.txt>", route("file"))
+	r.add(http.MethodGet, "/users/:id/files/*", route("any"))
+	r.add(http.MethodGet, "/numbers/:n<even>", route("even"))
+	r.add(http.MethodGet, "/numbers/:n<uint>", route("uint"))
+	c := e.NewContext(nil, nil).(*context)
+
+	assert := assert.New(t)
+
+	find := func(path string) interface{} {
+		c.Set("route", nil)
+		c.handler = NotFoundHandler
+		r.find(http.MethodGet, path, c)
+		c.handler(c)
+		return c.Get("route")
+	}
+
+	assert.Equal("id", find("/users/42"))
+	assert.Equal("42", c.Param("id"))
+	assert.Equal("/users/:id<int>", c.Path())
+	assert.Equal("name", find("/users/joe"))
+	assert.Equal("joe", c.Param("name"))
+	assert.Equal("file", find("/users/42/files/notes.txt"))
+	assert.Equal("42", c.Param("id"))
+	assert.Equal("notes.txt", c.Param("file"))
+	assert.Equal("any", find("/users/42/files/notes.pdf"))
+	assert.Equal("notes.pdf", c.Param("*"))
+	assert.Equal("any", find("/users/joe/files/notes.txt"))
+	assert.Equal("even", find("/numbers/4"))
+	assert.Equal("uint", find("/numbers/3"))
+	assert.Nil(find("/numbers/-3"))
+
+	assert.Panics(func() {
+		r.add(http.MethodGet, "/bad/:id<int", route("bad"))
+	})
+	assert.Panics(func() {
+		r.add(http.MethodGet, "/bad/:id<int>x", route("bad"))
+	})
+	assert.Panics(func() {
+		r.add(http.MethodGet, "/bad/:id<[a-z>", route("bad"))
+	})
+}
+
+func TestParamMatchers(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(paramMatchers["int"]("-12"))
+	assert.False(paramMatchers["int"]("-"))
+	assert.False(paramMatchers["int"]("1a"))
+	assert.True(paramMatchers["uint"]("12"))
+	assert.False(paramMatchers["uint"]("-12"))
+	assert.True(paramMatchers["alpha"]("abcXYZ"))
+	assert.False(paramMatchers["alpha"]("abc1"))
+	assert.True(paramMatchers["alnum"]("abc1"))
+	assert.False(paramMatchers["alnum"](""))
+	assert.True(paramMatchers["uuid"]("123e4567-e89b-12d3-a456-426614174000"))
+	assert.False(paramMatchers["uuid"]("123e4567"))
+}
+
 func TestRouterTwoParam(t *testing.T) {
 	e := New()
 	r := e.router
@@ -584,6 +692,74 @@ func TestRouterMatchAny(t *testing.T) {
 	assert.Equal(t, "joe", c.Param("*"))
 }
 
+func TestRouterHeadMatchAny(t *testing.T) {
+	e := New()
+	e.GET("/static/", func(c Context) error {
+		return c.String(http.StatusOK, "dir")
+	})
+	e.GET("/static/*", func(c Context) error {
+		return c.String(http.StatusOK, "wildcard handler")
+	})
+	e.GET("/a/", func(c Context) error {
+		return c.String(http.StatusOK, "a")
+	})
+	e.POST("/a/*", func(c Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+
+	// HEAD is answered by the GET handler of the node, not the any child
+	req := httptest.NewRequest(http.MethodHead, "/static/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "3", rec.Header().Get(HeaderContentLength))
+
+	req = httptest.NewRequest(http.MethodHead, "/a/", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get(HeaderContentLength))
+
+	code, _ := request(http.MethodPost, "/a/", e)
+	assert.Equal(t, http.StatusCreated, code)
+}
+
+func TestRouterHeadReplaceable(t *testing.T) {
+	e := New()
+	g := e.Group("/g", func(next HandlerFunc) HandlerFunc {
+		return next
+	})
+	g.GET("", func(c Context) error {
+		return c.String(http.StatusOK, "g")
+	})
+	g.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, "g/")
+	})
+	e.Any("/x", func(c Context) error {
+		return c.String(http.StatusOK, "any")
+	})
+	e.GET("/x", func(c Context) error {
+		return c.String(http.StatusOK, "get")
+	})
+
+	for path, length := range map[string]string{"/g": "1", "/g/": "2", "/x": "3"} {
+		req := httptest.NewRequest(http.MethodHead, path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, length, rec.Header().Get(HeaderContentLength), path)
+	}
+	code, _ := request(http.MethodHead, "/g/missing", e)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// An explicit HEAD route is kept
+	e.HEAD("/x", func(c Context) error {
+		return c.NoContent(http.StatusTeapot)
+	})
+	code, _ = request(http.MethodHead, "/x", e)
+	assert.Equal(t, http.StatusTeapot, code)
+}
+
 func TestRouterMatchAnyMultiLevel(t *testing.T) {
 	e := New()
 	r := e.router
@@ -867,9 +1043,9 @@ func TestRouterGitHubAPI(t *testing.T) {
 // Issue #729
 func TestRouterParamAlias(t *testing.T) {
 	api := []*Route{
-		{http.MethodGet, "/users/:userID/following", ""},
-		{http.MethodGet, "/users/:userID/followedBy", ""},
-		{http.MethodGet, "/users/:userID/follow", ""},
+		{Method: http.MethodGet, Path: "/users/:userID/following"},
+		{Method: http.MethodGet, Path: "/users/:userID/followedBy"},
+		{Method: http.MethodGet, Path: "/users/:userID/follow"},
 	}
 	testRouterAPI(t, api)
 }
@@ -877,21 +1053,21 @@ func TestRouterParamAlias(t *testing.T) {
 // Issue #1052
 func TestRouterParamOrdering(t *testing.T) {
 	api := []*Route{
-		{http.MethodGet, "/:a/:b/:c/:id", ""},
-		{http.MethodGet, "/:a/:id", ""},
-		{http.MethodGet, "/:a/:e/:id", ""},
+		{Method: http.MethodGet, Path: "/:a/:b/:c/:id"},
+		{Method: http.MethodGet, Path: "/:a/:id"},
+		{Method: http.MethodGet, Path: "/:a/:e/:id"},
 	}
 	testRouterAPI(t, api)
 	api2 := []*Route{
-		{http.MethodGet, "/:a/:id", ""},
-		{http.MethodGet, "/:a/:e/:id", ""},
-		{http.MethodGet, "/:a/:b/:c/:id", ""},
+		{Method: http.MethodGet, Path: "/:a/:id"},
+		{Method: http.MethodGet, Path: "/:a/:e/:id"},
+		{Method: http.MethodGet, Path: "/:a/:b/:c/:id"},
 	}
 	testRouterAPI(t, api2)
 	api3 := []*Route{
-		{http.MethodGet, "/:a/:b/:c/:id", ""},
-		{http.MethodGet, "/:a/:e/:id", ""},
-		{http.MethodGet, "/:a/:id", ""},
+		{Method: http.MethodGet, Path: "/:a/:b/:c/:id"},
+		{Method: http.MethodGet, Path: "/:a/:e/:id"},
+		{Method: http.MethodGet, Path: "/:a/:id"},
 	}
 	testRouterAPI(t, api3)
 }
@@ -899,13 +1075,13 @@ func TestRouterParamOrdering(t *testing.T) {
 // Issue #1139
 func TestRouterMixedParams(t *testing.T) {
 	api := []*Route{
-		{http.MethodGet, "/teacher/:tid/room/suggestions", ""},
-		{http.MethodGet, "/teacher/:id", ""},
+		{Method: http.MethodGet, Path: "/teacher/:tid/room/suggestions"},
+		{Method: http.MethodGet, Path: "/teacher/:id"},
 	}
 	testRouterAPI(t, api)
 	api2 := []*Route{
-		{http.MethodGet, "/teacher/:id", ""},
-		{http.MethodGet, "/teacher/:tid/room/suggestions", ""},
+		{Method: http.MethodGet, Path: "/teacher/:id"},
+		{Method: http.MethodGet, Path: "/teacher/:tid/room/suggestions"},
 	}
 	testRouterAPI(t, api2)
 }
@@ -969,3 +1145,26 @@ func prefix(tail bool, p, on, off string) string {
 	}
 	return fmt.Sprintf("%s%s", p, off)
 }
+
+func TestRoutePattern(t *testing.T) {
+	assert.Equal(t, "/users/:/files/:<int>/*", routePattern("users/:id/files/:file<int>/*"))
+	assert.Equal(t, "/", routePattern("/"))
+}
+
+func TestRouterDumpTree(t *testing.T) {
+	e := New()
+	h := func(Context) error { return nil }
+	e.GET("/users", h)
+	e.POST("/users", h)
+	e.GET("/users/:id<int>", h)
+	e.Host("api.example.com").GET("/", h)
+
+	buf := new(strings.Builder)
+	e.DumpTree(buf)
+	assert.Equal(t, "/users [GET, HEAD, POST] /users\n"+
+		"  /\n"+
+		"    :<int> [GET, HEAD] /users/:id<int>\n"+
+		"\n"+
+		"host api.example.com\n"+
+		"/ [GET, HEAD] /\n", buf.String())
+}
@@ -0,0 +1,172 @@
+package nio
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Event is a single Server-Sent Event.
+	// See: https://html.spec.whatwg.org/multipage/server-sent-events.html
+	Event struct {
+		// ID sets the event ID which the client sends back in the `Last-Event-ID`
+		// header when it reconnects.
+		ID string
+
+		// Event is the event type. Clients receive events without a type as
+		// "message" events.
+		Event string
+
+		// Retry tells the client how long to wait before reconnecting.
+		Retry time.Duration
+
+		// Data is the event payload. Multi-line data is sent as multiple
+		// `data` fields.
+		Data string
+	}
+
+	// EventStream writes Server-Sent Events to the client. It is safe to send
+	// events from multiple goroutines.
+	EventStream struct {
+		mu          sync.Mutex
+		context     Context
+		lastEventID string
+		done        <-chan struct{}
+	}
+)
+
+// ErrEventStreamClosed is returned when writing to an event stream whose
+// request has been cancelled or whose client has disconnected.
+var ErrEventStreamClosed = errors.New("event stream closed")
+
+func newEventStream(c Context) *EventStream {
+	req := c.Request()
+	s := &EventStream{
+		context:     c,
+		lastEventID: req.Header.Get(HeaderLastEventID),
+		done:        c.Done(),
+	}
+
+	header := c.Response().Header()
+	header.Set(HeaderContentType, MIMETextEventStream)
+	header.Set(HeaderCacheControl, "no-cache")
+	header.Set(HeaderConnection, "keep-alive")
+	c.Response().WriteHeader(http.StatusOK)
+	c.Response().Flush()
+	return s
+}
+
+// LastEventID returns the ID of the last event received by the client before
+// it reconnected, allowing the stream to be resumed.
+func (s *EventStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done returns a channel which is closed when the request is cancelled or the
+// client disconnects.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Send writes an event to the client and flushes it.
+func (s *EventStream) Send(e *Event) error {
+	b := new(strings.Builder)
+	if e.ID != "" {
+		writeEventField(b, "id", e.ID)
+	}
+	if e.Event != "" {
+		writeEventField(b, "event", e.Event)
+	}
+	if e.Retry > 0 {
+		writeEventField(b, "retry", strconv.FormatInt(int64(e.Retry/time.Millisecond), 10))
+	}
+	for _, line := range eventLines(e.Data) {
+		b.WriteString("data: ")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return s.write(b.String())
+}
+
+// Comment writes a comment line to the client. Comments are ignored by the
+// client and are mostly used to keep the connection alive.
+func (s *EventStream) Comment(text string) error {
+	b := new(strings.Builder)
+	for _, line := range eventLines(text) {
+		b.WriteString(": ")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return s.write(b.String())
+}
+
+// Serve sends the events received from the channel until it is closed or the
+// request is cancelled. If heartbeat is greater than zero a comment is sent at
+// that interval, so proxies don't drop an idle connection.
+func (s *EventStream) Serve(events <-chan *Event, heartbeat time.Duration) error {
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		t := time.NewTicker(heartbeat)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return ErrEventStreamClosed
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := s.Send(e); err != nil {
+				return err
+			}
+		case <-tick:
+			if err := s.Comment("heartbeat"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *EventStream) write(data string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return ErrEventStreamClosed
+	default:
+	}
+
+	res := s.context.Response()
+	if _, err = res.Write([]byte(data)); err != nil {
+		return
+	}
+	res.Flush()
+	return
+}
+
+// eventLines splits the text into lines, a line ends with CRLF, CR or LF like in
+// the event stream.
+func eventLines(text string) []string {
+	return strings.Split(lineBreaks.Replace(text), "\n")
+}
+
+var lineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+func writeEventField(b *strings.Builder, name, value string) {
+	// Field values can't span multiple lines.
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	b.WriteString(name)
+	b.WriteString(": ")
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
@@ -0,0 +1,83 @@
+package nio
+
+import (
+	stdcontext "context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextSSE(t *testing.T) {
+	e := New()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set(HeaderLastEventID, "41")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	assert := assert.New(t)
+
+	s := c.SSE()
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal(MIMETextEventStream, rec.Header().Get(HeaderContentType))
+	assert.Equal("no-cache", rec.Header().Get(HeaderCacheControl))
+	assert.Equal("41", s.LastEventID())
+	assert.True(rec.Flushed)
+
+	err := s.Send(&Event{
+		ID:    "42",
+		Event: "update",
+		Retry: 3 * time.Second,
+		Data:  "line 1\nline 2",
+	})
+	if assert.NoError(err) {
+		assert.Equal("id: 42\nevent: update\nretry: 3000\ndata: line 1\ndata: line 2\n\n", rec.Body.String())
+	}
+
+	rec.Body.Reset()
+	if assert.NoError(s.Comment("ping")) {
+		assert.Equal(": ping\n\n", rec.Body.String())
+	}
+
+	// Bare CR ends a line too, so it can't inject fields
+	rec.Body.Reset()
+	if assert.NoError(s.Send(&Event{Data: "hello\revent: admin\r\nid: 99"})) {
+		assert.Equal("data: hello\ndata: event: admin\ndata: id: 99\n\n", rec.Body.String())
+	}
+	rec.Body.Reset()
+	if assert.NoError(s.Comment("a\rb")) {
+		assert.Equal(": a\n: b\n\n", rec.Body.String())
+	}
+}
+
+func TestEventStreamServe(t *testing.T) {
+	e := New()
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	events := make(chan *Event, 2)
+	events <- &Event{Data: "a"}
+	events <- &Event{Data: "b"}
+	close(events)
+
+	if assert.NoError(t, c.SSE().Serve(events, time.Hour)) {
+		assert.Equal(t, "data: a\n\ndata: b\n\n", rec.Body.String())
+	}
+}
+
+func TestEventStreamCancelled(t *testing.T) {
+	e := New()
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	s := c.SSE()
+	cancel()
+	assert.Equal(t, ErrEventStreamClosed, s.Serve(make(chan *Event), 0))
+	assert.Equal(t, ErrEventStreamClosed, s.Send(&Event{Data: "late"}))
+	assert.Empty(t, rec.Body.String())
+}
@@ -0,0 +1,132 @@
+package nio
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Typed adapts a handler which takes a typed request and returns a typed
+// response to a `HandlerFunc`.
+//
+// The request is bound from the request body, if present, using the registered
+// `Binder`, then from query params (`query` tag) and path params (`param` tag),
+// so values from the URL take precedence. Only fields with the tag are bound
+// from the URL, so it can't override other fields. If a `Validator` is
+// registered the request is validated afterwards. Binding and validation
+// failures result in a 400 error.
+//
+// The response is sent as XML if the client prefers it over JSON in the Accept
+// header and as JSON otherwise. A nil response or a response of an empty struct
+// type sends 204 No Content. Errors returned by the handler are passed to the
+// `HTTPErrorHandler`.
+func Typed[Req, Resp any](h func(Context, Req) (Resp, error)) HandlerFunc {
+	return func(c Context) error {
+		var req Req
+		if err := bindTyped(c, &req); err != nil {
+			return err
+		}
+		resp, err := h(c, req)
+		if err != nil {
+			return err
+		}
+		if isEmptyResponse(resp) {
+			return c.NoContent(http.StatusNoContent)
+		}
+		if prefersXML(c.Request().Header.Get(HeaderAccept)) {
+			return c.XML(http.StatusOK, resp)
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func bindTyped(c Context, i interface{}) error {
+	v := reflect.ValueOf(i).Elem()
+	if v.Kind() == reflect.Ptr {
+		// Request is a pointer, allocate the value it points to.
+		v.Set(reflect.New(v.Type().Elem()))
+		i = v.Interface()
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || v.NumField() == 0 {
+		return nil
+	}
+
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(i); err != nil {
+			return err
+		}
+	}
+	b := &DefaultBinder{}
+	if err := b.bindTaggedData(i, c.QueryParams(), "query"); err != nil {
+		return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	if names := c.ParamNames(); len(names) > 0 {
+		values := c.ParamValues()
+		params := make(map[string][]string, len(names))
+		for n, name := range names {
+			if n < len(values) {
+				params[name] = []string{values[n]}
+			}
+		}
+		if err := b.bindTaggedData(i, params, "param"); err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+	}
+
+	if err := c.Validate(i); err != nil && err != ErrValidatorNotRegistered {
+		if he, ok := err.(*HTTPError); ok {
+			return he
+		}
+		return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return nil
+}
+
+func isEmptyResponse(resp interface{}) bool {
+	v := reflect.ValueOf(resp)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Struct:
+		return v.NumField() == 0
+	}
+	return false
+}
+
+// prefersXML reports whether the Accept header prefers XML over JSON. Ties are
+// resolved in favour of JSON.
+func prefersXML(accept string) bool {
+	if accept == "" {
+		return false
+	}
+	jsonQ, xmlQ := -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mime, q := part, 1.0
+		if i := strings.IndexByte(part, ';'); i != -1 {
+			mime = part[:i]
+			for _, param := range strings.Split(part[i+1:], ";") {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if f, err := strconv.ParseFloat(param[2:], 64); err == nil {
+						q = f
+					}
+				}
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(mime)) {
+		case MIMEApplicationJSON:
+			if q > jsonQ {
+				jsonQ = q
+			}
+		case MIMEApplicationXML, MIMETextXML:
+			if q > xmlQ {
+				xmlQ = q
+			}
+		}
+	}
+	return xmlQ > 0 && xmlQ > jsonQ
+}
@@ -0,0 +1,134 @@
+package nio
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	typedRequest struct {
+		ID    int    `param:"id" json:"-"`
+		Sort  string `query:"sort" json:"-"`
+		Title string `json:"title"`
+		Admin bool   `json:"-"`
+	}
+
+	typedResponse struct {
+		ID    int    `json:"id" xml:"id"`
+		Sort  string `json:"sort" xml:"sort"`
+		Title string `json:"title" xml:"title"`
+	}
+
+	typedValidator struct{}
+)
+
+func (typedValidator) Validate(i interface{}) error {
+	if r, ok := i.(*typedRequest); ok && r.Title == "" {
+		return errors.New("title is required")
+	}
+	return nil
+}
+
+func TestTyped(t *testing.T) {
+	e := New()
+	e.POST("/items/:id", Typed(func(c Context, req typedRequest) (*typedResponse, error) {
+		return &typedResponse{ID: req.ID, Sort: req.Sort, Title: req.Title}, nil
+	}))
+
+	assert := assert.New(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/items/7?sort=asc", strings.NewReader(`{"title":"milk"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal(MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Equal(`{"id":7,"sort":"asc","title":"milk"}`, rec.Body.String())
+
+	// Query doesn't override untagged fields
+	req = httptest.NewRequest(http.MethodPost, "/items/7?title=evil&admin=true&sort=asc", strings.NewReader(`{"title":"milk"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	var admin bool
+	e.POST("/admin/:id", Typed(func(c Context, req typedRequest) (*typedResponse, error) {
+		admin = req.Admin
+		return &typedResponse{ID: req.ID, Sort: req.Sort, Title: req.Title}, nil
+	}))
+	e.ServeHTTP(rec, req)
+	assert.Equal(`{"id":7,"sort":"asc","title":"milk"}`, rec.Body.String())
+	req = httptest.NewRequest(http.MethodPost, "/admin/7?title=evil&admin=true&Admin=true", strings.NewReader(`{"title":"milk"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(admin)
+
+	// XML preferred
+	req = httptest.NewRequest(http.MethodPost, "/items/7", strings.NewReader(`{"title":"milk"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	req.Header.Set(HeaderAccept, "application/json;q=0.5, application/xml")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal(MIMEApplicationXMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Contains(rec.Body.String(), "<title>milk</title>")
+
+	// Invalid path param
+	req = httptest.NewRequest(http.MethodPost, "/items/abc", strings.NewReader(`{"title":"milk"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func TestTypedValidate(t *testing.T) {
+	e := New(WithValidator(typedValidator{}))
+	e.POST("/items/:id", Typed(func(c Context, req *typedRequest) (*typedResponse, error) {
+		return nil, nil
+	}))
+
+	assert := assert.New(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/items/1", strings.NewReader(`{"title":""}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(http.StatusBadRequest, rec.Code)
+	assert.Contains(rec.Body.String(), "title is required")
+
+	req = httptest.NewRequest(http.MethodPost, "/items/1", strings.NewReader(`{"title":"milk"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(http.StatusNoContent, rec.Code)
+	assert.Empty(rec.Body.String())
+}
+
+func TestTypedError(t *testing.T) {
+	e := New()
+	e.GET("/", Typed(func(c Context, req struct{}) (struct{}, error) {
+		return struct{}{}, ErrForbidden
+	}))
+	e.GET("/empty", Typed(func(c Context, req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+
+	code, _ := request(http.MethodGet, "/", e)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = request(http.MethodGet, "/empty", e)
+	assert.Equal(t, http.StatusNoContent, code)
+}
+
+func TestPrefersXML(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(prefersXML(""))
+	assert.False(prefersXML("*/*"))
+	assert.False(prefersXML("application/json, application/xml"))
+	assert.True(prefersXML("application/xml"))
+	assert.True(prefersXML("text/xml;q=0.9, application/json;q=0.8"))
+	assert.False(prefersXML("application/xml;q=0"))
+}
@@ -0,0 +1,138 @@
+package nio
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+type (
+	// vhost is a router selected by a request predicate, see `Nio#Host()`,
+	// `Nio#Header()` and `Nio#When()`.
+	vhost struct {
+		names []string // Host param names
+		match func(r *http.Request) (values []string, ok bool)
+		nio   *Nio
+	}
+)
+
+// Host returns a group for the routes of requests to the host. The pattern is
+// a host name without port, where a `:name` label matches any label and is
+// available as param, e.g. `:tenant.example.com`, and a leading `*` label
+// matches one or more labels, e.g. `*.example.com`.
+//
+// Requests matching a host are routed only with the routes of its group, in
+// the order the hosts, headers and predicates were registered. Other requests
+// are routed with the routes of the nio instance.
+func (e *Nio) Host(pattern string) *Group {
+	labels := strings.Split(strings.ToLower(pattern), ".")
+	var names []string
+	for _, l := range labels {
+		if strings.HasPrefix(l, ":") {
+			names = append(names, l[1:])
+		}
+	}
+	return e.vhost(pattern, names, func(r *http.Request) ([]string, bool) {
+		return matchHost(labels, len(names), requestHost(r))
+	})
+}
+
+// Header returns a group for the routes of requests with the header value,
+// e.g. an API version header. See `Nio#Host()` for the routing order.
+func (e *Nio) Header(key, value string) *Group {
+	return e.When(func(r *http.Request) bool {
+		return r.Header.Get(key) == value
+	})
+}
+
+// When returns a group for the routes of requests matching the predicate. See
+// `Nio#Host()` for the routing order.
+func (e *Nio) When(predicate func(r *http.Request) bool) *Group {
+	return e.vhost("", nil, func(r *http.Request) ([]string, bool) {
+		return nil, predicate(r)
+	})
+}
+
+func (e *Nio) vhost(host string, names []string, match func(*http.Request) ([]string, bool)) *Group {
+	if e.matchers == nil {
+		e.matchers = map[string]ParamMatcher{} // Shared with the virtual host
+	}
+	v := &Nio{
+		maxParam:      e.maxParam,
+		matchers:      e.matchers,
+		autoOptions:   e.autoOptions,
+		strictRouting: e.strictRouting,
+		logger:        e.logger,
+		host:          host,
+	}
+	v.router = newRouter(v)
+	v.router.names = e.router.names // Route names are unique across hosts
+	e.vhosts = append(e.vhosts, &vhost{names: names, match: match, nio: v})
+	return &Group{nio: v}
+}
+
+// find finds the route of the request in the router of the first matching
+// virtual host, or the router of the nio instance.
+func (e *Nio) find(r *http.Request, c Context) {
+	for _, v := range e.vhosts {
+		values, ok := v.match(r)
+		if !ok {
+			continue
+		}
+		v.nio.router.find(r.Method, getPath(r), c)
+		if len(values) > 0 {
+			c.(*context).addParams(v.names, values)
+		}
+		return
+	}
+	e.router.find(r.Method, getPath(r), c)
+}
+
+// addParams appends host params to the path params found by the router.
+func (c *context) addParams(names, values []string) {
+	n := len(c.pnames)
+	pnames := make([]string, 0, n+len(names))
+	pnames = append(pnames, c.pnames...)
+	c.pnames = append(pnames, names...) // Don't modify the slice of the route
+	if l := n + len(values); len(c.pvalues) < l {
+		c.pvalues = append(c.pvalues, make([]string, l-len(c.pvalues))...)
+	}
+	copy(c.pvalues[n:], values)
+}
+
+// matchHost matches the host against the pattern labels, returning the values
+// of the `:name` labels.
+func matchHost(labels []string, params int, host string) ([]string, bool) {
+	hl := strings.Split(strings.ToLower(host), ".")
+	if labels[0] == "*" {
+		if len(hl) < len(labels) {
+			return nil, false
+		}
+		// Wildcard consumes the leading labels
+		labels, hl = labels[1:], hl[len(hl)-len(labels)+1:]
+	} else if len(hl) != len(labels) {
+		return nil, false
+	}
+	var values []string
+	if params > 0 {
+		values = make([]string, 0, params)
+	}
+	for i, l := range labels {
+		switch {
+		case strings.HasPrefix(l, ":") && hl[i] != "":
+			values = append(values, hl[i])
+		case l != hl[i]:
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// requestHost returns the host of the request without port.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
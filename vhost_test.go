@@ -0,0 +1,88 @@
+package nio
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNioHost(t *testing.T) {
+	e := New()
+	h := func(c Context) error {
+		return c.String(http.StatusOK, c.Param("tenant")+" "+c.Param("id"))
+	}
+	e.GET("/users/:id", func(c Context) error { return c.String(http.StatusOK, "default") })
+	api := e.Host("api.example.com")
+	api.GET("/users/:id", func(c Context) error { return c.String(http.StatusOK, "api "+c.Param("id")) })
+	e.Host(":tenant.example.com").GET("/users/:id", h).SetName("tenantUser")
+	e.Host("*.internal.example.com").GET("/", func(c Context) error { return c.String(http.StatusOK, "internal") })
+
+	assert := assert.New(t)
+	serve := func(host, path string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	_, body := serve("api.example.com", "/users/1")
+	assert.Equal("api 1", body)
+	_, body = serve("API.example.com:8080", "/users/1")
+	assert.Equal("api 1", body)
+	_, body = serve("acme.example.com", "/users/2")
+	assert.Equal("acme 2", body)
+	_, body = serve("a.b.internal.example.com", "/")
+	assert.Equal("internal", body)
+	_, body = serve("example.com", "/users/3")
+	assert.Equal("default", body)
+	_, body = serve("internal.example.com", "/users/3")
+	assert.Equal("internal 3", body)
+
+	// Host routes are not shared
+	code, _ := serve("a.internal.example.com", "/users/1")
+	assert.Equal(http.StatusNotFound, code)
+
+	// Introspection and reverse routing
+	routes := e.Routes()
+	if assert.Len(routes, 4) {
+		assert.Equal("", routes[1].Host)
+		assert.Equal(":tenant.example.com", routes[2].Host)
+		assert.Equal("api.example.com", routes[3].Host)
+	}
+	assert.Equal("/users/1", e.Reverse("tenantUser", 1))
+}
+
+func TestNioHeader(t *testing.T) {
+	e := New()
+	e.GET("/users", func(c Context) error { return c.String(http.StatusOK, "v1") })
+	e.Header("X-API-Version", "2").GET("/users", func(c Context) error { return c.String(http.StatusOK, "v2") })
+	e.When(func(r *http.Request) bool {
+		return r.URL.Query().Get("beta") != ""
+	}).GET("/users", func(c Context) error { return c.String(http.StatusOK, "beta") })
+
+	assert := assert.New(t)
+	for header, expected := range map[string]string{"": "v1", "1": "v1", "2": "v2"} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("X-API-Version", header)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(expected, rec.Body.String())
+	}
+	_, body := request(http.MethodGet, "/users?beta=1", e)
+	assert.Equal("beta", body)
+}
+
+func TestMatchHost(t *testing.T) {
+	values, ok := matchHost([]string{":a", ":b", "com"}, 2, "x.y.com")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"x", "y"}, values)
+	_, ok = matchHost([]string{":a", "com"}, 1, "com")
+	assert.False(t, ok)
+	_, ok = matchHost([]string{"*", "com"}, 0, "com")
+	assert.False(t, ok)
+	_, ok = matchHost([]string{"*", "com"}, 0, "a.org")
+	assert.False(t, ok)
+}
@@ -0,0 +1,518 @@
+package nio
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+type (
+	// WebSocketConfig defines the config for WebSocket handlers.
+	WebSocketConfig struct {
+		// AllowOrigins defines a list of origins that may open a connection. It
+		// uses the same format as `mw.CORSConfig.AllowOrigins`, so the CORS
+		// allow-list can be shared.
+		// Optional. Default value none, which only allows same-origin requests.
+		AllowOrigins []string `yaml:"allow_origins"`
+
+		// CheckOrigin defines a function to validate the request origin. It
+		// overrides AllowOrigins.
+		// Optional.
+		CheckOrigin func(Context) bool
+
+		// Subprotocols defines the supported subprotocols in order of preference.
+		// Optional. Default value []string{}.
+		Subprotocols []string `yaml:"subprotocols"`
+
+		// MaxMessageSize is the maximum size in bytes of a message read from the
+		// peer. Larger messages close the connection with `WebSocketCloseMessageTooBig`.
+		// Optional. Default value 1MB.
+		MaxMessageSize int64 `yaml:"max_message_size"`
+	}
+
+	// WebSocketHandler defines a function to serve WebSocket connections.
+	WebSocketHandler func(Context, *WebSocketConn) error
+
+	// WebSocketConn is a WebSocket connection as defined by RFC 6455.
+	// See: https://tools.ietf.org/html/rfc6455
+	WebSocketConn struct {
+		conn           net.Conn
+		reader         *bufio.Reader
+		writer         *bufio.Writer
+		wmu            sync.Mutex
+		closeSent      bool
+		subprotocol    string
+		maxMessageSize int64
+		pongHandler    func([]byte)
+	}
+
+	// WebSocketCloseError is returned when the connection is closed by the peer
+	// or because of a protocol violation.
+	WebSocketCloseError struct {
+		Code int
+		Text string
+	}
+)
+
+// WebSocket message types
+const (
+	WebSocketContinuationMessage = 0
+	WebSocketTextMessage         = 1
+	WebSocketBinaryMessage       = 2
+	WebSocketCloseMessage        = 8
+	WebSocketPingMessage         = 9
+	WebSocketPongMessage         = 10
+)
+
+// WebSocket close codes
+const (
+	WebSocketCloseNormalClosure    = 1000
+	WebSocketCloseGoingAway        = 1001
+	WebSocketCloseProtocolError    = 1002
+	WebSocketCloseUnsupportedData  = 1003
+	WebSocketCloseNoStatusReceived = 1005
+	WebSocketCloseAbnormalClosure  = 1006
+	WebSocketCloseInvalidPayload   = 1007
+	WebSocketClosePolicyViolation  = 1008
+	WebSocketCloseMessageTooBig    = 1009
+	WebSocketCloseInternalError    = 1011
+)
+
+const (
+	webSocketGUID          = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	webSocketMaxControlLen = 125
+)
+
+var (
+	// DefaultWebSocketConfig is the default WebSocket config.
+	DefaultWebSocketConfig = WebSocketConfig{
+		MaxMessageSize: 1 << 20, // 1 MB
+	}
+
+	// ErrWebSocketClosed is returned when writing to a closed connection.
+	ErrWebSocketClosed = errors.New("websocket: connection closed")
+)
+
+// WebSocket returns a handler which upgrades the connection to the WebSocket
+// protocol and calls h with it.
+func WebSocket(h WebSocketHandler) HandlerFunc {
+	return WebSocketWithConfig(DefaultWebSocketConfig, h)
+}
+
+// WebSocketWithConfig returns a WebSocket handler with config.
+// See: `WebSocket()`.
+func WebSocketWithConfig(config WebSocketConfig, h WebSocketHandler) HandlerFunc {
+	// Defaults
+	if config.MaxMessageSize == 0 {
+		config.MaxMessageSize = DefaultWebSocketConfig.MaxMessageSize
+	}
+	if config.CheckOrigin == nil {
+		config.CheckOrigin = webSocketOriginChecker(config.AllowOrigins)
+	}
+
+	return func(c Context) error {
+		ws, err := upgradeWebSocket(c, config)
+		if err != nil {
+			return err
+		}
+		err = h(c, ws)
+		ws.finish(err)
+		if ce, ok := err.(*WebSocketCloseError); ok && (ce.Code == WebSocketCloseNormalClosure || ce.Code == WebSocketCloseGoingAway) {
+			return nil
+		}
+		return err
+	}
+}
+
+func upgradeWebSocket(c Context, config WebSocketConfig) (*WebSocketConn, error) {
+	req := c.Request()
+	res := c.Response()
+
+	if req.Method != http.MethodGet {
+		return nil, NewHTTPError(http.StatusMethodNotAllowed, "websocket: request method is not GET")
+	}
+	if !headerHasToken(req.Header, HeaderConnection, "upgrade") {
+		return nil, NewHTTPError(http.StatusBadRequest, "websocket: 'upgrade' token not found in 'Connection' header")
+	}
+	if !headerHasToken(req.Header, HeaderUpgrade, "websocket") {
+		return nil, NewHTTPError(http.StatusBadRequest, "websocket: 'websocket' token not found in 'Upgrade' header")
+	}
+	if req.Header.Get(HeaderSecWebSocketVersion) != "13" {
+		res.Header().Set(HeaderSecWebSocketVersion, "13")
+		return nil, NewHTTPError(http.StatusUpgradeRequired, "websocket: unsupported version")
+	}
+	key := req.Header.Get(HeaderSecWebSocketKey)
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return nil, NewHTTPError(http.StatusBadRequest, "websocket: invalid 'Sec-WebSocket-Key' header")
+	}
+	if !config.CheckOrigin(c) {
+		return nil, NewHTTPError(http.StatusForbidden, "websocket: origin not allowed")
+	}
+	if _, ok := res.Writer.(http.Hijacker); !ok {
+		return nil, errors.New("websocket: response does not implement http.Hijacker")
+	}
+
+	subprotocol := selectSubprotocol(req.Header, config.Subprotocols)
+
+	conn, brw, err := res.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	hs := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		HeaderSecWebSocketAccept + ": " + webSocketAcceptKey(key) + "\r\n"
+	if subprotocol != "" {
+		hs += HeaderSecWebSocketProtocol + ": " + subprotocol + "\r\n"
+	}
+	hs += "\r\n"
+	if _, err = brw.WriteString(hs); err == nil {
+		err = brw.Flush()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	res.Status = http.StatusSwitchingProtocols
+	res.Committed = true
+
+	return &WebSocketConn{
+		conn:           conn,
+		reader:         brw.Reader,
+		writer:         brw.Writer,
+		subprotocol:    subprotocol,
+		maxMessageSize: config.MaxMessageSize,
+	}, nil
+}
+
+// Subprotocol returns the negotiated subprotocol.
+func (ws *WebSocketConn) Subprotocol() string {
+	return ws.subprotocol
+}
+
+// RemoteAddr returns the remote network address.
+func (ws *WebSocketConn) RemoteAddr() net.Addr {
+	return ws.conn.RemoteAddr()
+}
+
+// SetReadDeadline sets the deadline for future reads.
+func (ws *WebSocketConn) SetReadDeadline(t time.Time) error {
+	return ws.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for future writes.
+func (ws *WebSocketConn) SetWriteDeadline(t time.Time) error {
+	return ws.conn.SetWriteDeadline(t)
+}
+
+// SetPongHandler sets the function called with the payload of every pong
+// received from the peer.
+func (ws *WebSocketConn) SetPongHandler(h func([]byte)) {
+	ws.pongHandler = h
+}
+
+// ReadMessage reads the next text or binary message from the peer. Pings are
+// answered and pongs are handled while reading. If the peer closes the
+// connection a `*WebSocketCloseError` is returned.
+func (ws *WebSocketConn) ReadMessage() (messageType int, p []byte, err error) {
+	var (
+		fin     bool
+		opcode  int
+		payload []byte
+	)
+	for {
+		fin, opcode, payload, err = ws.readFrame(ws.maxMessageSize - int64(len(p)))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case WebSocketPingMessage:
+			if err = ws.writeFrame(WebSocketPongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case WebSocketPongMessage:
+			if ws.pongHandler != nil {
+				ws.pongHandler(payload)
+			}
+			continue
+		case WebSocketCloseMessage:
+			return 0, nil, ws.closeReceived(payload)
+		case WebSocketContinuationMessage:
+			if messageType == 0 {
+				return 0, nil, ws.fail(WebSocketCloseProtocolError, "unexpected continuation frame")
+			}
+		case WebSocketTextMessage, WebSocketBinaryMessage:
+			if messageType != 0 {
+				return 0, nil, ws.fail(WebSocketCloseProtocolError, "expected continuation frame")
+			}
+			messageType = opcode
+		default:
+			return 0, nil, ws.fail(WebSocketCloseProtocolError, fmt.Sprintf("unknown opcode %d", opcode))
+		}
+
+		p = append(p, payload...)
+		if fin {
+			if messageType == WebSocketTextMessage && !utf8.Valid(p) {
+				return 0, nil, ws.fail(WebSocketCloseInvalidPayload, "invalid utf-8 in text message")
+			}
+			return messageType, p, nil
+		}
+	}
+}
+
+// WriteMessage writes a text or binary message to the peer.
+func (ws *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	if messageType != WebSocketTextMessage && messageType != WebSocketBinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", messageType)
+	}
+	return ws.writeFrame(messageType, data)
+}
+
+// Ping sends a ping with optional application data to the peer.
+func (ws *WebSocketConn) Ping(data []byte) error {
+	if len(data) > webSocketMaxControlLen {
+		return errors.New("websocket: control frame payload too large")
+	}
+	return ws.writeFrame(WebSocketPingMessage, data)
+}
+
+// Close sends a close frame with code and reason and closes the underlying
+// connection.
+func (ws *WebSocketConn) Close(code int, text string) error {
+	err := ws.writeClose(code, text)
+	if cerr := ws.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (ws *WebSocketConn) readFrame(limit int64) (fin bool, opcode int, payload []byte, err error) {
+	var h [8]byte
+	if _, err = io.ReadFull(ws.reader, h[:2]); err != nil {
+		return
+	}
+	fin = h[0]&0x80 != 0
+	opcode = int(h[0] & 0x0f)
+	if h[0]&0x70 != 0 {
+		err = ws.fail(WebSocketCloseProtocolError, "reserved bits set")
+		return
+	}
+	if h[1]&0x80 == 0 {
+		err = ws.fail(WebSocketCloseProtocolError, "client frame not masked")
+		return
+	}
+
+	n := int64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		if _, err = io.ReadFull(ws.reader, h[:2]); err != nil {
+			return
+		}
+		n = int64(binary.BigEndian.Uint16(h[:2]))
+	case 127:
+		if _, err = io.ReadFull(ws.reader, h[:8]); err != nil {
+			return
+		}
+		n = int64(binary.BigEndian.Uint64(h[:8]))
+		if n < 0 {
+			err = ws.fail(WebSocketCloseProtocolError, "invalid payload length")
+			return
+		}
+	}
+
+	if opcode >= WebSocketCloseMessage {
+		if !fin || n > webSocketMaxControlLen {
+			err = ws.fail(WebSocketCloseProtocolError, "invalid control frame")
+			return
+		}
+	} else if n > limit {
+		err = ws.fail(WebSocketCloseMessageTooBig, "message too big")
+		return
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(ws.reader, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(ws.reader, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return
+}
+
+func (ws *WebSocketConn) writeFrame(opcode int, data []byte) error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+
+	if ws.closeSent {
+		return ErrWebSocketClosed
+	}
+	if opcode == WebSocketCloseMessage {
+		ws.closeSent = true
+	}
+
+	var h [10]byte
+	h[0] = 0x80 | byte(opcode)
+	n := len(data)
+	l := 2
+	switch {
+	case n <= 125:
+		h[1] = byte(n)
+	case n <= 0xffff:
+		h[1] = 126
+		binary.BigEndian.PutUint16(h[2:], uint16(n))
+		l += 2
+	default:
+		h[1] = 127
+		binary.BigEndian.PutUint64(h[2:], uint64(n))
+		l += 8
+	}
+	if _, err := ws.writer.Write(h[:l]); err != nil {
+		return err
+	}
+	if _, err := ws.writer.Write(data); err != nil {
+		return err
+	}
+	return ws.writer.Flush()
+}
+
+func (ws *WebSocketConn) writeClose(code int, text string) error {
+	var payload []byte
+	if code != WebSocketCloseNoStatusReceived {
+		payload = make([]byte, 2, 2+len(text))
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		payload = append(payload, text...)
+	}
+	return ws.writeFrame(WebSocketCloseMessage, payload)
+}
+
+// closeReceived answers a close frame received from the peer.
+func (ws *WebSocketConn) closeReceived(payload []byte) error {
+	ce := &WebSocketCloseError{Code: WebSocketCloseNoStatusReceived}
+	switch {
+	case len(payload) == 1:
+		return ws.fail(WebSocketCloseProtocolError, "invalid close frame")
+	case len(payload) >= 2:
+		ce.Code = int(binary.BigEndian.Uint16(payload))
+		ce.Text = string(payload[2:])
+		if !validCloseCode(ce.Code) {
+			return ws.fail(WebSocketCloseProtocolError, "invalid close code")
+		}
+		if !utf8.ValidString(ce.Text) {
+			return ws.fail(WebSocketCloseInvalidPayload, "invalid utf-8 in close reason")
+		}
+	}
+	ws.writeClose(ce.Code, "")
+	return ce
+}
+
+// fail closes the connection because of an error detected while reading.
+func (ws *WebSocketConn) fail(code int, text string) error {
+	ws.Close(code, text)
+	return &WebSocketCloseError{Code: code, Text: text}
+}
+
+// finish closes the connection once the handler returns.
+func (ws *WebSocketConn) finish(err error) {
+	code := WebSocketCloseNormalClosure
+	if _, ok := err.(*WebSocketCloseError); !ok && err != nil {
+		code = WebSocketCloseInternalError
+	}
+	ws.Close(code, "")
+}
+
+// Error makes it compatible with `error` interface.
+func (e *WebSocketCloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code < 1000 || code > 1011:
+		return false
+	}
+	switch code {
+	case 1004, WebSocketCloseNoStatusReceived, WebSocketCloseAbnormalClosure:
+		return false
+	}
+	return true
+}
+
+func webSocketAcceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func webSocketOriginChecker(allowOrigins []string) func(Context) bool {
+	return func(c Context) bool {
+		req := c.Request()
+		origin := req.Header.Get(HeaderOrigin)
+		if origin == "" {
+			// Not a browser
+			return true
+		}
+		if len(allowOrigins) == 0 {
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, req.Host)
+		}
+		for _, o := range allowOrigins {
+			if o == "*" || o == origin {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func selectSubprotocol(header http.Header, supported []string) string {
+	requested := headerTokens(header, HeaderSecWebSocketProtocol)
+	for _, s := range supported {
+		for _, r := range requested {
+			if r == s {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func headerTokens(header http.Header, name string) (tokens []string) {
+	for _, v := range header[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return
+}
+
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
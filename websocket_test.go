@@ -0,0 +1,222 @@
+package nio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testWebSocketKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+func TestWebSocketAcceptKey(t *testing.T) {
+	// Example from RFC 6455, section 1.3
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", webSocketAcceptKey(testWebSocketKey))
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	e := New()
+	e.WS("/ws", func(c Context, ws *WebSocketConn) error {
+		for {
+			mt, p, err := ws.ReadMessage()
+			if err != nil {
+				return err
+			}
+			if err = ws.WriteMessage(mt, p); err != nil {
+				return err
+			}
+		}
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	assert := assert.New(t)
+
+	conn, br := dialWebSocket(t, srv, http.Header{})
+	defer conn.Close()
+
+	// Text message
+	writeWebSocketFrame(conn, true, WebSocketTextMessage, []byte("hello"))
+	op, p := readWebSocketFrame(t, br)
+	assert.Equal(WebSocketTextMessage, op)
+	assert.Equal("hello", string(p))
+
+	// Fragmented binary message
+	writeWebSocketFrame(conn, false, WebSocketBinaryMessage, []byte{1, 2})
+	writeWebSocketFrame(conn, true, WebSocketContinuationMessage, []byte{3})
+	op, p = readWebSocketFrame(t, br)
+	assert.Equal(WebSocketBinaryMessage, op)
+	assert.Equal([]byte{1, 2, 3}, p)
+
+	// Ping
+	writeWebSocketFrame(conn, true, WebSocketPingMessage, []byte("ping"))
+	op, p = readWebSocketFrame(t, br)
+	assert.Equal(WebSocketPongMessage, op)
+	assert.Equal("ping", string(p))
+
+	// Close
+	writeWebSocketFrame(conn, true, WebSocketCloseMessage, []byte{0x03, 0xe8})
+	op, p = readWebSocketFrame(t, br)
+	assert.Equal(WebSocketCloseMessage, op)
+	assert.Equal(WebSocketCloseNormalClosure, int(binary.BigEndian.Uint16(p)))
+}
+
+func TestWebSocketMessageTooBig(t *testing.T) {
+	e := New()
+	e.GET("/ws", WebSocketWithConfig(WebSocketConfig{MaxMessageSize: 4}, func(c Context, ws *WebSocketConn) error {
+		_, _, err := ws.ReadMessage()
+		return err
+	}))
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	conn, br := dialWebSocket(t, srv, http.Header{})
+	defer conn.Close()
+
+	writeWebSocketFrame(conn, true, WebSocketTextMessage, []byte("too big"))
+	op, p := readWebSocketFrame(t, br)
+	assert.Equal(t, WebSocketCloseMessage, op)
+	assert.Equal(t, WebSocketCloseMessageTooBig, int(binary.BigEndian.Uint16(p)))
+}
+
+func TestWebSocketSubprotocol(t *testing.T) {
+	e := New()
+	e.GET("/ws", WebSocketWithConfig(WebSocketConfig{Subprotocols: []string{"v2", "v1"}}, func(c Context, ws *WebSocketConn) error {
+		return ws.WriteMessage(WebSocketTextMessage, []byte(ws.Subprotocol()))
+	}))
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	header := http.Header{}
+	header.Set(HeaderSecWebSocketProtocol, "v1, v2")
+	conn, br := dialWebSocket(t, srv, header)
+	defer conn.Close()
+
+	_, p := readWebSocketFrame(t, br)
+	assert.Equal(t, "v2", string(p))
+}
+
+func TestWebSocketHandshakeErrors(t *testing.T) {
+	e := New()
+	e.WS("/ws", func(c Context, ws *WebSocketConn) error {
+		return nil
+	})
+
+	assert := assert.New(t)
+
+	// Not an upgrade request
+	code, _ := request(http.MethodGet, "/ws", e)
+	assert.Equal(http.StatusBadRequest, code)
+
+	// Cross-origin request
+	req := newWebSocketRequest("/ws")
+	req.Header.Set(HeaderOrigin, "http://evil.com")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(http.StatusForbidden, rec.Code)
+
+	// Unsupported version
+	req = newWebSocketRequest("/ws")
+	req.Header.Set(HeaderSecWebSocketVersion, "8")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(http.StatusUpgradeRequired, rec.Code)
+	assert.Equal("13", rec.Header().Get(HeaderSecWebSocketVersion))
+
+	// HEAD request
+	e.Group("/g").WS("/ws", func(c Context, ws *WebSocketConn) error {
+		return nil
+	})
+	for _, path := range []string{"/ws", "/g/ws"} {
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, path, nil))
+		assert.Equal(http.StatusMethodNotAllowed, rec.Code)
+		assert.Equal("GET", rec.Header().Get(HeaderAllow))
+	}
+}
+
+func TestWebSocketOriginChecker(t *testing.T) {
+	e := New()
+	req := newWebSocketRequest("/ws")
+	c := e.NewContext(req, nil)
+
+	assert := assert.New(t)
+
+	req.Header.Set(HeaderOrigin, "http://example.com")
+	assert.True(webSocketOriginChecker(nil)(c))
+	assert.True(webSocketOriginChecker([]string{"*"})(c))
+	assert.True(webSocketOriginChecker([]string{"http://example.com"})(c))
+	assert.False(webSocketOriginChecker([]string{"http://nio.dev"})(c))
+
+	req.Header.Set(HeaderOrigin, "http://nio.dev")
+	assert.False(webSocketOriginChecker(nil)(c))
+}
+
+func newWebSocketRequest(path string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Host = "example.com"
+	req.Header.Set(HeaderConnection, "Upgrade")
+	req.Header.Set(HeaderUpgrade, "websocket")
+	req.Header.Set(HeaderSecWebSocketVersion, "13")
+	req.Header.Set(HeaderSecWebSocketKey, testWebSocketKey)
+	return req
+}
+
+func dialWebSocket(t *testing.T, srv *httptest.Server, header http.Header) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req := newWebSocketRequest(srv.URL + "/ws")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if err = req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode) {
+		t.FailNow()
+	}
+	assert.Equal(t, webSocketAcceptKey(testWebSocketKey), res.Header.Get(HeaderSecWebSocketAccept))
+	return conn, br
+}
+
+func writeWebSocketFrame(w io.Writer, fin bool, opcode int, payload []byte) {
+	b := byte(opcode)
+	if fin {
+		b |= 0x80
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{b, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	for i, p := range payload {
+		frame = append(frame, p^mask[i%4])
+	}
+	w.Write(frame)
+}
+
+func readWebSocketFrame(t *testing.T, r io.Reader) (int, []byte) {
+	h := make([]byte, 2)
+	if _, err := io.ReadFull(r, h); err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, h[1]&0x7f)
+	if _, err := io.ReadFull(r, p); err != nil {
+		t.Fatal(err)
+	}
+	return int(h[0] & 0x0f), p
+}